@@ -3,6 +3,8 @@ package config
 var ApiModule string
 var BaseCfg BaseConfig
 var RedisCfg RedisConfig
+var HttpCfg HttpConfig
+var TraceCfg TraceConfig
 
 // BaseConfig base前缀
 type BaseConfig struct {
@@ -27,6 +29,8 @@ type BaseServer struct {
 	Port      int           `yaml:"port"`      // 端口号
 	Gin       BaseGin       `yaml:"gin"`       // web框架gin的配置
 	Exception BaseException `yaml:"exception"` // 异常处理
+	AccessLog BaseAccessLog `yaml:"accessLog"` // 访问日志
+	Page      BasePage      `yaml:"page"`      // 分页
 }
 
 type BaseGin struct {
@@ -55,6 +59,22 @@ type ExceptionPrint struct {
 	Except []int `yaml:"except"` // 排除的httpStatus；默认可不填
 }
 
+type BaseAccessLog struct {
+	Enable          bool     `yaml:"enable"`          // 是否记录所有请求；默认只记录异常的请求
+	LoggerName      string   `yaml:"loggerName"`      // 日志的名字，通过log.GetLogger获取；默认gole-access，日志文件没有配置时输出到控制台
+	SampleRatio     float64  `yaml:"sampleRatio"`     // 正常请求的采样比例，取值0~1；默认1，异常的请求都记录
+	ShowHead        bool     `yaml:"showHead"`        // 是否记录请求头；默认不记录
+	MaxBodySize     int      `yaml:"maxBodySize"`     // 记录的请求体和响应体的最大长度，超过则截断；默认1024
+	RedactHeaders   []string `yaml:"redactHeaders"`   // 替换为***的请求头；默认Authorization、Cookie、Set-Cookie
+	RedactJsonPaths []string `yaml:"redactJsonPaths"` // 替换为***的json路径，比如：user.password、items[*].token
+	RedactQueries   []string `yaml:"redactQueries"`   // 替换为***的url参数和表单参数，比如：token
+}
+
+type BasePage struct {
+	DefaultSize int `yaml:"defaultSize"` // 没有传size时的每页条数；默认20
+	MaxSize     int `yaml:"maxSize"`     // 每页的最大条数，超过则按照最大条数查询；默认1000
+}
+
 type BaseLogger struct {
 	Level string      `yaml:"level"` // 日志root级别：trace/debug/info/warn/error/fatal/panic，默认：info
 	Time  LoggerTime  `yaml:"time"`  // 时间配置
@@ -170,3 +190,170 @@ type RedisClusterConfig struct {
 	// 允许将只读命令路由到随机的主节点或从节点，它会自动启用 ReadOnly
 	RouteRandomly bool
 }
+
+// ---------------------------- http ----------------------------
+// base.http前缀
+type HttpConfig struct {
+	// 重试
+	Retry HttpRetryConfig
+	// 熔断，按照host区分
+	CircuitBreaker HttpCircuitBreakerConfig
+	// 命名的客户端，key为客户端的名字
+	Clients map[string]HttpClientConfig
+	// 服务的实例地址，用于lb://服务名/路径 的服务发现，key为服务名，值为host:port列表
+	Services map[string][]string
+	// 负载均衡策略：roundRobin/random/leastPending，默认roundRobin
+	LoadBalancer string
+	// 异常实例摘除
+	OutlierDetection HttpOutlierDetectionConfig
+	// GET请求的响应缓存
+	Cache HttpCacheConfig
+	// 限流，运行时修改base.http.limits的配置立即生效
+	Limits []HttpLimitConfig
+	// 调用日志
+	Log HttpLogConfig
+}
+
+// base.http.retry
+type HttpRetryConfig struct {
+	// 是否启用，默认不启用
+	Enable bool
+	// 最大尝试次数，包括第一次请求，默认3次
+	MaxAttempts int
+	// （单位毫秒）第一次重试前的回退时间，之后每次翻倍，默认100毫秒
+	InitialBackoff int
+	// （单位毫秒）最大回退时间，默认2秒
+	MaxBackoff int
+	// 回退时间的随机抖动比例，取值0~1，默认0.2
+	Jitter float64
+	// 需要重试的http状态码，默认：429、502、503、504；网络错误总是重试
+	StatusCodes []int
+	// 非幂等的方法（POST、PATCH）是否也重试，默认不重试
+	RetryNonIdempotent bool
+}
+
+// base.http.circuitBreaker
+type HttpCircuitBreakerConfig struct {
+	// 是否启用，默认不启用
+	Enable bool
+	// 连续失败多少次后熔断，网络错误和5xx都算失败，默认5次
+	FailureThreshold int
+	// （单位毫秒）熔断多久后进入半开状态，默认30秒
+	OpenTimeout int
+	// 半开状态下允许通过的探测请求数，默认1个
+	HalfOpenRequests int
+}
+
+// base.http.outlierDetection
+type HttpOutlierDetectionConfig struct {
+	// 实例连续失败多少次后摘除，网络错误和5xx都算失败，默认5次，-1则不摘除
+	ConsecutiveFailures int
+	// （单位毫秒）摘除的时长，多次摘除时按照次数翻倍，默认30秒
+	EjectionTime int
+	// 最多摘除的实例比例，取值0~100，默认50
+	MaxEjectionPercent int
+}
+
+// base.http.cache
+type HttpCacheConfig struct {
+	// 是否启用，默认不启用
+	Enable bool
+	// 存储：memory/redis，默认memory；redis使用base.redis的配置
+	Store string
+	// 内存存储最多缓存的响应数，超过后淘汰最久没有使用的，默认1000
+	Capacity int
+	// redis存储的key前缀，默认gole:http:cache:
+	KeyPrefix string
+	// （单位毫秒）过期后可以用于协商（If-None-Match、If-Modified-Since）的保留时长，默认10分钟
+	Retention int
+}
+
+// base.http.limits[i]
+type HttpLimitConfig struct {
+	// 限流的host（包括端口），和Client二选一
+	Host string
+	// 限流的命名客户端
+	Client string
+	// 每秒允许的请求数，默认不限制
+	Rate float64
+	// 允许突发的请求数，默认为Rate向上取整
+	Burst int
+	// 同时进行的最大请求数，默认不限制
+	MaxInFlight int
+	// 超过限制时直接返回错误，默认等待
+	FailFast bool
+}
+
+// base.http.log
+type HttpLogConfig struct {
+	// 日志的名字，通过log.GetLogger获取，默认gole-http；日志文件没有配置时输出到控制台
+	LoggerName string
+	// 调用日志的最低级别：debug/info/warn/error，默认info；成功的调用为debug，4xx和重试为warn，5xx和网络错误为error
+	Level string
+	// 是否记录请求头、请求体和响应体，默认不记录
+	LogBody bool
+	// 记录的请求体和响应体的最大长度，超过则截断，默认1024
+	MaxBodySize int
+	// 替换为***的请求头，默认Authorization、Cookie、Set-Cookie
+	RedactHeaders []string
+	// 替换为***的json字段和url参数，比如password、token
+	RedactFields []string
+}
+
+// base.http.clients.<name>
+type HttpClientConfig struct {
+	// 基础地址，请求的url不是绝对地址时拼接在前面
+	BaseUrl string
+	// 默认的请求头
+	Headers map[string]string
+
+	// ----- 超时配置 -----
+	// （单位毫秒）调用的超时时间，默认20秒
+	Timeout int
+	// （单位毫秒）建立连接的超时时间，默认30秒
+	DialTimeout int
+	// （单位毫秒）等待响应头的超时时间，默认不限制
+	ResponseHeaderTimeout int
+
+	// ----- 连接池相关配置 -----
+	// 最大空闲连接数，默认100
+	MaxIdleConns int
+	// 每个host的最大空闲连接数，默认100
+	MaxIdleConnsPerHost int
+	// 每个host的最大连接数，默认不限制
+	MaxConnsPerHost int
+	// （单位毫秒）空闲连接的超时时间，默认90秒
+	IdleConnTimeout int
+
+	// 代理地址，比如：http://127.0.0.1:3128，默认使用环境变量HTTP_PROXY、HTTPS_PROXY的配置
+	Proxy string
+	// tls配置
+	Tls HttpTlsConfig
+}
+
+// base.http.clients.<name>.tls
+type HttpTlsConfig struct {
+	// 服务端证书的CA文件路径，默认使用系统的CA
+	CaFile string
+	// 客户端证书文件路径，双向认证时配置
+	CertFile string
+	// 客户端证书的私钥文件路径
+	KeyFile string
+	// 是否跳过服务端证书的校验
+	InsecureSkipVerify bool
+}
+
+// ---------------------------- trace ----------------------------
+// base.trace前缀
+type TraceConfig struct {
+	// 服务名，默认为base.application.name
+	ServiceName string
+	// 新建的链路的采样比例，取值0~1，默认1；上游传入的链路按照上游的采样标记
+	SampleRatio float64
+	// span的导出：otlp/file，默认不导出
+	Exporter string
+	// otlp的地址（OTLP/HTTP json），默认http://localhost:4318/v1/traces
+	OtlpEndpoint string
+	// file导出的文件路径，每行一个span的json，默认./logs/trace.json
+	FilePath string
+}
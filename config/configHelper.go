@@ -450,6 +450,12 @@ func AppendValue(propertiesNewValue string) {
 		return
 	}
 	appProperty.ValueDeepMap = resultDeepMap
+
+	var keys []string
+	for k := range pMap {
+		keys = append(keys, k)
+	}
+	notifyChange(keys)
 }
 
 func SetValue(key, value string) {
@@ -477,6 +483,43 @@ func SetValue(key, value string) {
 		return
 	}
 	appProperty.ValueDeepMap = resultDeepMap
+	notifyChange([]string{key})
+}
+
+// ChangeListener 配置变更的监听，keys为变更的配置项
+type ChangeListener func(keys []string)
+
+type changeListenerEntry struct {
+	prefix   string
+	listener ChangeListener
+}
+
+var changeListeners []changeListenerEntry
+var changeListenerLock sync.RWMutex
+
+// AddChangeListener 监听prefix开头的配置，SetValue和AppendValue修改了这些配置后回调，一次修改只回调一次
+func AddChangeListener(prefix string, listener ChangeListener) {
+	changeListenerLock.Lock()
+	defer changeListenerLock.Unlock()
+	changeListeners = append(changeListeners, changeListenerEntry{prefix: prefix, listener: listener})
+}
+
+func notifyChange(keys []string) {
+	changeListenerLock.RLock()
+	listeners := append([]changeListenerEntry{}, changeListeners...)
+	changeListenerLock.RUnlock()
+
+	for _, entry := range listeners {
+		var changedKeys []string
+		for _, key := range keys {
+			if strings.HasPrefix(key, entry.prefix) {
+				changedKeys = append(changedKeys, key)
+			}
+		}
+		if len(changedKeys) > 0 {
+			entry.listener(changedKeys)
+		}
+	}
 }
 
 func GetValueString(key string) string {
@@ -136,3 +136,21 @@ func TestReadLocal78(t *testing.T) {
 //		}
 //	}
 //}
+
+func TestChangeListener(t *testing.T) {
+	config.LoadConfig()
+
+	var changed [][]string
+	config.AddChangeListener("base.listener", func(keys []string) {
+		changed = append(changed, keys)
+	})
+
+	config.SetValue("base.listener.rate", "5")
+	config.AppendValue("base.listener.burst=10\nbase.other=1")
+	config.SetValue("base.other", "2")
+
+	assert.Equal(t, len(changed), 2)
+	assert.Equal(t, changed[0], []string{"base.listener.rate"})
+	assert.Equal(t, changed[1], []string{"base.listener.burst"})
+	assert.Equal(t, config.GetValueInt("base.listener.burst"), 10)
+}
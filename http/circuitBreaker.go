@@ -0,0 +1,120 @@
+package http
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitBreakerPolicy 熔断策略，按照host区分
+type CircuitBreakerPolicy struct {
+	// 连续失败多少次后熔断
+	FailureThreshold int
+	// 熔断多久后进入半开状态
+	OpenTimeout time.Duration
+	// 半开状态下允许通过的探测请求数
+	HalfOpenRequests int
+}
+
+// CircuitOpenError 熔断中，请求没有发出
+type CircuitOpenError struct {
+	Host string
+}
+
+func (error *CircuitOpenError) Error() string {
+	return "circuit breaker is open, host: " + error.Host
+}
+
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuitBreaker struct {
+	lock     sync.Mutex
+	policy   *CircuitBreakerPolicy
+	state    int
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+var circuitBreakerPolicy *CircuitBreakerPolicy
+var circuitBreakers sync.Map
+var circuitBreakerLock sync.RWMutex
+
+// DefaultCircuitBreakerPolicy 默认的熔断策略
+func DefaultCircuitBreakerPolicy() *CircuitBreakerPolicy {
+	return &CircuitBreakerPolicy{FailureThreshold: 5, OpenTimeout: 30 * time.Second, HalfOpenRequests: 1}
+}
+
+// SetCircuitBreakerPolicy 设置熔断策略，nil则不熔断；已有的熔断状态会被清空
+func SetCircuitBreakerPolicy(policy *CircuitBreakerPolicy) {
+	circuitBreakerLock.Lock()
+	defer circuitBreakerLock.Unlock()
+	circuitBreakerPolicy = policy
+	circuitBreakers.Range(func(key, value interface{}) bool {
+		circuitBreakers.Delete(key)
+		return true
+	})
+}
+
+func getCircuitBreaker(host string) *circuitBreaker {
+	circuitBreakerLock.RLock()
+	defer circuitBreakerLock.RUnlock()
+	policy := circuitBreakerPolicy
+	if policy == nil {
+		return nil
+	}
+	breaker, _ := circuitBreakers.LoadOrStore(host, &circuitBreaker{policy: policy})
+	return breaker.(*circuitBreaker)
+}
+
+// 是否允许请求通过，熔断超时后转为半开，只放行有限的探测请求
+func (breaker *circuitBreaker) allow() bool {
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	switch breaker.state {
+	case circuitOpen:
+		if time.Since(breaker.openedAt) < breaker.policy.OpenTimeout {
+			return false
+		}
+		breaker.state = circuitHalfOpen
+		breaker.probes = 0
+		fallthrough
+	case circuitHalfOpen:
+		if breaker.probes >= breaker.policy.HalfOpenRequests {
+			return false
+		}
+		breaker.probes++
+	}
+	return true
+}
+
+func (breaker *circuitBreaker) onResult(success bool) {
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	if success {
+		breaker.state = circuitClosed
+		breaker.failures = 0
+		return
+	}
+
+	breaker.failures++
+	if breaker.state == circuitHalfOpen || breaker.failures >= breaker.policy.FailureThreshold {
+		breaker.state = circuitOpen
+		breaker.openedAt = time.Now()
+	}
+}
+
+// 请求没有得到结果（比如调用方取消），归还半开状态下的探测名额
+func (breaker *circuitBreaker) release() {
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	if breaker.state == circuitHalfOpen && breaker.probes > 0 {
+		breaker.probes--
+	}
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"github.com/isyscore/gole/config"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/**
+ * 服务发现：url为 lb://服务名/路径 时，通过Resolver获取服务的实例地址，经过负载均衡选择一个实例后发送请求；
+ * lb://使用http，lbs://使用https
+ */
+
+// Resolver 服务发现，返回服务的实例地址列表，格式为host:port
+type Resolver interface {
+	Resolve(ctx context.Context, serviceName string) ([]string, error)
+}
+
+// DiscoveryError 服务发现失败或者服务没有可用的实例
+type DiscoveryError struct {
+	ServiceName string
+	Err         error
+}
+
+func (error *DiscoveryError) Error() string {
+	if error.Err == nil {
+		return "no instance available, service: " + error.ServiceName
+	}
+	return "resolve service " + error.ServiceName + " err: " + error.Err.Error()
+}
+
+func (error *DiscoveryError) Unwrap() error {
+	return error.Err
+}
+
+var resolver Resolver = &ConfigResolver{}
+var resolverLock sync.RWMutex
+
+// SetResolver 设置服务发现，默认为ConfigResolver
+func SetResolver(serviceResolver Resolver) {
+	resolverLock.Lock()
+	defer resolverLock.Unlock()
+	resolver = serviceResolver
+}
+
+func getResolver() Resolver {
+	resolverLock.RLock()
+	defer resolverLock.RUnlock()
+	return resolver
+}
+
+// ------------------ static ------------------
+
+// StaticResolver 固定的实例列表，key为服务名
+type StaticResolver map[string][]string
+
+func (staticResolver StaticResolver) Resolve(ctx context.Context, serviceName string) ([]string, error) {
+	return staticResolver[serviceName], nil
+}
+
+// ------------------ config ------------------
+
+// ConfigResolver 从配置base.http.services.<服务名>中读取实例列表，每次都读取最新的配置
+type ConfigResolver struct{}
+
+func (configResolver *ConfigResolver) Resolve(ctx context.Context, serviceName string) ([]string, error) {
+	value := config.GetValue("base.http.services." + serviceName)
+	instances, ok := value.([]interface{})
+	if !ok {
+		if value == nil {
+			return nil, nil
+		}
+		for _, instance := range strings.Split(fmt.Sprintf("%v", value), ",") {
+			instances = append(instances, instance)
+		}
+	}
+
+	var result []string
+	for _, instance := range instances {
+		result = append(result, strings.TrimSpace(fmt.Sprintf("%v", instance)))
+	}
+	return result, nil
+}
+
+// ------------------ dns srv ------------------
+
+// DnsSrvResolver 通过DNS的SRV记录发现实例，查询 _Service._Proto.服务名；Service为空则直接查询服务名
+type DnsSrvResolver struct {
+	Service string
+	Proto   string
+	// Resolver 为nil时使用net.DefaultResolver
+	Resolver *net.Resolver
+}
+
+func (dnsResolver *DnsSrvResolver) Resolve(ctx context.Context, serviceName string) ([]string, error) {
+	netResolver := dnsResolver.Resolver
+	if netResolver == nil {
+		netResolver = net.DefaultResolver
+	}
+
+	_, records, err := netResolver.LookupSRV(ctx, dnsResolver.Service, dnsResolver.Proto, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, record := range records {
+		result = append(result, net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port))))
+	}
+	return result, nil
+}
@@ -0,0 +1,476 @@
+package http
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/isyscore/gole/redis"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/**
+ * GET请求的响应缓存（RFC 7234，客户端私有缓存）：按照Cache-Control、Expires计算新鲜度，
+ * 过期后通过ETag（If-None-Match）和Last-Modified（If-Modified-Since）协商，304时使用缓存的响应；
+ * 响应有Vary时，url的key下只保存Vary的请求头名，响应按照这些请求头的值分别保存；
+ * private的响应、带Authorization或者Cookie请求的非public响应不缓存，流式请求（Stream、Download）不经过缓存；
+ * 作为拦截器使用：http.AddInterceptor(cache.Interceptor())，或者配置base.http.cache.enable=true
+ */
+
+// CacheEntry 缓存的响应
+type CacheEntry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// 响应的时间
+	StoredAt time.Time
+	// 新鲜的时长，为0时每次都需要协商
+	Lifetime time.Duration
+	// 响应头Vary对应的请求头的值；StatusCode为0时表示只记录了Vary的请求头名
+	VaryHeader http.Header
+}
+
+// CacheStore 缓存的存储，key不存在时返回nil
+type CacheStore interface {
+	Get(ctx context.Context, key string) (*CacheEntry, error)
+	Set(ctx context.Context, key string, entry *CacheEntry, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// CacheStats 缓存的命中统计，协商后304的也算命中
+type CacheStats struct {
+	Hits        int64
+	Misses      int64
+	Revalidated int64
+}
+
+// Cache 响应缓存
+type Cache struct {
+	// Retention 过期后还可以用于协商的保留时长，默认10分钟
+	Retention time.Duration
+
+	store       CacheStore
+	hits        int64
+	misses      int64
+	revalidated int64
+}
+
+var configCache *Cache
+
+// ConfigCache 通过base.http.cache配置启用的缓存，没有启用时为nil
+func ConfigCache() *Cache {
+	return configCache
+}
+
+// NewCache 使用存储创建缓存
+func NewCache(store CacheStore) *Cache {
+	return &Cache{Retention: 10 * time.Minute, store: store}
+}
+
+// Stats 命中统计
+func (cache *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:        atomic.LoadInt64(&cache.hits),
+		Misses:      atomic.LoadInt64(&cache.misses),
+		Revalidated: atomic.LoadInt64(&cache.revalidated),
+	}
+}
+
+// Invalidate 删除url的缓存，按照Vary分别保存的响应随之失效
+func (cache *Cache) Invalidate(ctx context.Context, url string) error {
+	return cache.store.Delete(ctx, url)
+}
+
+// Interceptor 缓存拦截器：只缓存GET；请求自带了协商头或者Cache-Control: no-store的不使用缓存；其他方法成功后删除该url的缓存
+func (cache *Cache) Interceptor() Interceptor {
+	return func(req *http.Request, next Handler) (*http.Response, error) {
+		// 流式的响应体不能读到内存中
+		if isStream(req.Context()) {
+			return next(req)
+		}
+
+		key := req.URL.String()
+		if req.Method != http.MethodGet {
+			rsp, err := next(req)
+			if req.Method != http.MethodHead && req.Method != http.MethodOptions && err == nil && rsp.StatusCode < http.StatusBadRequest {
+				if err := cache.store.Delete(req.Context(), key); err != nil {
+					getLogger().Errorf("delete http cache err, url: %v, %v", key, err.Error())
+				}
+			}
+			return rsp, err
+		}
+
+		requestControl := parseCacheControl(req.Header)
+		if _, ok := requestControl["no-store"]; ok || req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+			return next(req)
+		}
+
+		entry, err := cache.lookup(req.Context(), key, req.Header)
+		if err != nil {
+			getLogger().Errorf("read http cache err, url: %v, %v", key, err.Error())
+		}
+		if entry != nil && !entry.matchVary(req.Header) {
+			entry = nil
+		}
+
+		_, noCache := requestControl["no-cache"]
+		if entry != nil && !noCache && entry.fresh(time.Now()) {
+			atomic.AddInt64(&cache.hits, 1)
+			return entry.response(req), nil
+		}
+
+		// 过期的响应带上协商头
+		etag, lastModified := "", ""
+		if entry != nil {
+			etag, lastModified = entry.Header.Get("ETag"), entry.Header.Get("Last-Modified")
+		}
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+
+		rsp, err := next(req)
+		req.Header.Del("If-None-Match")
+		req.Header.Del("If-Modified-Since")
+		if err != nil {
+			atomic.AddInt64(&cache.misses, 1)
+			return rsp, err
+		}
+
+		if rsp.StatusCode == http.StatusNotModified && (etag != "" || lastModified != "") {
+			_ = rsp.Body.Close()
+			atomic.AddInt64(&cache.hits, 1)
+			atomic.AddInt64(&cache.revalidated, 1)
+			// 304的响应头更新缓存的响应头
+			for name, values := range rsp.Header {
+				entry.Header[name] = values
+			}
+			entry.StoredAt = time.Now()
+			entry.Lifetime, _ = freshnessLifetime(entry.Header, entry.StoredAt)
+			cache.save(req, key, entry)
+			return entry.response(req), nil
+		}
+
+		atomic.AddInt64(&cache.misses, 1)
+		if !cacheable(req, rsp) {
+			return rsp, nil
+		}
+		body, err := ioutil.ReadAll(rsp.Body)
+		_ = rsp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		rsp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		entry = &CacheEntry{StatusCode: rsp.StatusCode, Header: rsp.Header.Clone(), Body: body, StoredAt: time.Now()}
+		entry.Lifetime, _ = freshnessLifetime(rsp.Header, entry.StoredAt)
+		for _, name := range strings.Split(rsp.Header.Get("Vary"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				if entry.VaryHeader == nil {
+					entry.VaryHeader = http.Header{}
+				}
+				entry.VaryHeader[http.CanonicalHeaderKey(name)] = req.Header.Values(name)
+			}
+		}
+		cache.save(req, key, entry)
+		return rsp, nil
+	}
+}
+
+// 读取url的缓存，有Vary时再按照请求头的值读取对应的响应
+func (cache *Cache) lookup(ctx context.Context, key string, header http.Header) (*CacheEntry, error) {
+	entry, err := cache.store.Get(ctx, key)
+	if err != nil || entry == nil || entry.StatusCode != 0 {
+		return entry, err
+	}
+	return cache.store.Get(ctx, varyKey(key, entry.VaryHeader, header))
+}
+
+// 有协商头的响应保留到过期后Retention，没有的只保留到过期；有Vary时url的key下保存请求头名，响应保存在按照请求头的值的key下
+func (cache *Cache) save(req *http.Request, key string, entry *CacheEntry) {
+	ttl := entry.Lifetime - entry.age(entry.StoredAt)
+	if entry.Header.Get("ETag") != "" || entry.Header.Get("Last-Modified") != "" {
+		ttl += cache.Retention
+	}
+	if ttl <= 0 {
+		return
+	}
+	if len(entry.VaryHeader) > 0 {
+		varyNames := http.Header{}
+		for name := range entry.VaryHeader {
+			varyNames[name] = nil
+		}
+		if err := cache.store.Set(req.Context(), key, &CacheEntry{Header: http.Header{}, VaryHeader: varyNames, StoredAt: entry.StoredAt}, ttl); err != nil {
+			getLogger().Errorf("write http cache err, url: %v, %v", key, err.Error())
+			return
+		}
+		key = varyKey(key, entry.VaryHeader, entry.VaryHeader)
+	}
+	if err := cache.store.Set(req.Context(), key, entry, ttl); err != nil {
+		getLogger().Errorf("write http cache err, url: %v, %v", key, err.Error())
+	}
+}
+
+// url加上Vary的请求头的值的摘要，请求头的值（比如Authorization）不直接出现在key中
+func varyKey(key string, varyNames http.Header, header http.Header) string {
+	var names []string
+	for name := range varyNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := sha1.New()
+	for _, name := range names {
+		hash.Write([]byte(name + ":" + strings.Join(header.Values(name), ",") + "\n"))
+	}
+	return key + "#vary:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// 可以缓存的响应：200、203、301、404、410，并且没有no-store、private、Vary: *，有新鲜时长或者协商头；
+// 带Authorization或者Cookie的请求只缓存public的响应
+func cacheable(req *http.Request, rsp *http.Response) bool {
+	switch rsp.StatusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+	default:
+		return false
+	}
+	control := parseCacheControl(rsp.Header)
+	if _, ok := control["no-store"]; ok || strings.TrimSpace(rsp.Header.Get("Vary")) == "*" {
+		return false
+	}
+	if _, ok := control["private"]; ok {
+		return false
+	}
+	if _, ok := control["public"]; !ok && (req.Header.Get("Authorization") != "" || req.Header.Get("Cookie") != "") {
+		return false
+	}
+	_, explicit := freshnessLifetime(rsp.Header, time.Now())
+	return explicit || rsp.Header.Get("ETag") != "" || rsp.Header.Get("Last-Modified") != ""
+}
+
+// 新鲜的时长：no-cache为0，然后依次为max-age、Expires - Date；都没有时为(Date - Last-Modified)的10%；explicit表示是否有明确的过期时间
+func freshnessLifetime(header http.Header, now time.Time) (lifetime time.Duration, explicit bool) {
+	control := parseCacheControl(header)
+	if _, ok := control["no-cache"]; ok {
+		return 0, true
+	}
+	if maxAge, ok := control["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil || seconds < 0 {
+			return 0, true
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		date = now
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		expiresTime, err := http.ParseTime(expires)
+		if err != nil || !expiresTime.After(date) {
+			return 0, true
+		}
+		return expiresTime.Sub(date), true
+	}
+	if lastModified, err := http.ParseTime(header.Get("Last-Modified")); err == nil && date.After(lastModified) {
+		return date.Sub(lastModified) / 10, false
+	}
+	return 0, false
+}
+
+func parseCacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, argument := directive, ""
+			if index := strings.Index(directive, "="); index >= 0 {
+				name, argument = directive[:index], strings.Trim(directive[index+1:], "\"")
+			}
+			directives[strings.ToLower(name)] = argument
+		}
+	}
+	return directives
+}
+
+// 响应的年龄：响应头Age加上缓存后经过的时间
+func (entry *CacheEntry) age(now time.Time) time.Duration {
+	age := now.Sub(entry.StoredAt)
+	if seconds, err := strconv.Atoi(entry.Header.Get("Age")); err == nil && seconds > 0 {
+		age += time.Duration(seconds) * time.Second
+	}
+	return age
+}
+
+func (entry *CacheEntry) fresh(now time.Time) bool {
+	return entry.age(now) < entry.Lifetime
+}
+
+func (entry *CacheEntry) matchVary(header http.Header) bool {
+	for name, values := range entry.VaryHeader {
+		if strings.Join(header.Values(name), ",") != strings.Join(values, ",") {
+			return false
+		}
+	}
+	return true
+}
+
+func (entry *CacheEntry) response(req *http.Request) *http.Response {
+	header := entry.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(entry.age(time.Now())/time.Second)))
+	return &http.Response{
+		Status:        strconv.Itoa(entry.StatusCode) + " " + http.StatusText(entry.StatusCode),
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}
+}
+
+// ------------------ memory ------------------
+
+// MemoryCacheStore 内存的LRU存储
+type MemoryCacheStore struct {
+	lock     sync.Mutex
+	capacity int
+	items    *list.List
+	index    map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key      string
+	entry    *CacheEntry
+	expireAt time.Time
+}
+
+// NewMemoryCacheStore 创建内存存储，超过capacity后淘汰最久没有使用的
+func NewMemoryCacheStore(capacity int) *MemoryCacheStore {
+	return &MemoryCacheStore{capacity: capacity, items: list.New(), index: map[string]*list.Element{}}
+}
+
+func (store *MemoryCacheStore) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	element := store.index[key]
+	if element == nil {
+		return nil, nil
+	}
+	item := element.Value.(*memoryCacheItem)
+	if !time.Now().Before(item.expireAt) {
+		store.items.Remove(element)
+		delete(store.index, key)
+		return nil, nil
+	}
+	store.items.MoveToFront(element)
+	// 返回副本，调用方修改时不影响缓存
+	entry := *item.entry
+	entry.Header = item.entry.Header.Clone()
+	return &entry, nil
+}
+
+func (store *MemoryCacheStore) Set(ctx context.Context, key string, entry *CacheEntry, ttl time.Duration) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	item := &memoryCacheItem{key: key, entry: entry, expireAt: time.Now().Add(ttl)}
+	if element := store.index[key]; element != nil {
+		element.Value = item
+		store.items.MoveToFront(element)
+		return nil
+	}
+	store.index[key] = store.items.PushFront(item)
+	for store.capacity > 0 && store.items.Len() > store.capacity {
+		oldest := store.items.Back()
+		store.items.Remove(oldest)
+		delete(store.index, oldest.Value.(*memoryCacheItem).key)
+	}
+	return nil
+}
+
+func (store *MemoryCacheStore) Delete(ctx context.Context, key string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	if element := store.index[key]; element != nil {
+		store.items.Remove(element)
+		delete(store.index, key)
+	}
+	return nil
+}
+
+// Len 缓存的响应数
+func (store *MemoryCacheStore) Len() int {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	return store.items.Len()
+}
+
+// ------------------ redis ------------------
+
+// RedisCacheStore redis存储，响应以json保存
+type RedisCacheStore struct {
+	client    goredis.UniversalClient
+	keyPrefix string
+}
+
+// NewRedisCacheStore 使用redis.GetClient()创建redis存储
+func NewRedisCacheStore(keyPrefix string) (*RedisCacheStore, error) {
+	client, err := redis.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	return NewRedisCacheStoreWithClient(client, keyPrefix), nil
+}
+
+// NewRedisCacheStoreWithClient 使用指定的redis客户端创建redis存储
+func NewRedisCacheStoreWithClient(client goredis.UniversalClient, keyPrefix string) *RedisCacheStore {
+	return &RedisCacheStore{client: client, keyPrefix: keyPrefix}
+}
+
+func (store *RedisCacheStore) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	content, err := store.client.Get(ctx, store.keyPrefix+key).Bytes()
+	if err == goredis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry := &CacheEntry{}
+	if err := json.Unmarshal(content, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (store *RedisCacheStore) Set(ctx context.Context, key string, entry *CacheEntry, ttl time.Duration) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return store.client.Set(ctx, store.keyPrefix+key, content, ttl).Err()
+}
+
+func (store *RedisCacheStore) Delete(ctx context.Context, key string) error {
+	return store.client.Del(ctx, store.keyPrefix+key).Err()
+}
@@ -0,0 +1,190 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/isyscore/gole/config"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HttpClient 客户端：底层的http.Client、该客户端的拦截器以及基础地址、超时和默认请求头
+type HttpClient struct {
+	client       *http.Client
+	interceptors []Interceptor
+	lock         sync.RWMutex
+
+	name    string
+	baseUrl string
+	timeout time.Duration
+	headers http.Header
+}
+
+var clientMap = map[string]*HttpClient{}
+var clientLock sync.RWMutex
+
+// NewClient 创建客户端，client为nil则使用默认的连接池配置
+func NewClient(client *http.Client, interceptors ...Interceptor) *HttpClient {
+	if client == nil {
+		client = createHTTPClient()
+	}
+	return &HttpClient{client: client, interceptors: interceptors, headers: http.Header{}}
+}
+
+// NewClientWithConfig 按照配置创建客户端
+func NewClientWithConfig(clientConfig config.HttpClientConfig) (*HttpClient, error) {
+	transport, err := createTransport(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := NewClient(&http.Client{Transport: transport})
+	httpClient.baseUrl = clientConfig.BaseUrl
+	httpClient.timeout = time.Duration(clientConfig.Timeout) * time.Millisecond
+	for key, value := range clientConfig.Headers {
+		httpClient.headers.Set(key, value)
+	}
+	return httpClient, nil
+}
+
+// Client 获取base.http.clients下配置的客户端；没有配置的则创建默认配置的客户端
+func Client(name string) *HttpClient {
+	clientLock.RLock()
+	httpClient, exist := clientMap[name]
+	clientLock.RUnlock()
+	if exist {
+		return httpClient
+	}
+
+	clientLock.Lock()
+	defer clientLock.Unlock()
+	if httpClient, exist := clientMap[name]; exist {
+		return httpClient
+	}
+	getLogger().Warnf("http client %v is not configured, use default config", name)
+	httpClient = NewClient(nil)
+	httpClient.name = name
+	clientMap[name] = httpClient
+	return httpClient
+}
+
+// RegisterClient 注册命名的客户端，同名的会被覆盖
+func RegisterClient(name string, httpClient *HttpClient) {
+	clientLock.Lock()
+	defer clientLock.Unlock()
+	httpClient.name = name
+	clientMap[name] = httpClient
+}
+
+// AddInterceptor 添加该客户端的拦截器，在全局拦截器之后执行
+func (httpClient *HttpClient) AddInterceptor(interceptors ...Interceptor) {
+	httpClient.lock.Lock()
+	defer httpClient.lock.Unlock()
+	httpClient.interceptors = append(httpClient.interceptors, interceptors...)
+}
+
+// Name 客户端的名字，非命名的客户端为空
+func (httpClient *HttpClient) Name() string {
+	return httpClient.name
+}
+
+// New 创建使用该客户端的请求构造器
+func (httpClient *HttpClient) New() *Request {
+	return New().Client(httpClient)
+}
+
+// 依次经过全局拦截器、客户端拦截器后发送请求
+func (httpClient *HttpClient) do(httpRequest *http.Request) (*http.Response, error) {
+	httpClient.lock.RLock()
+	interceptors := append(getGlobalInterceptors(), httpClient.interceptors...)
+	httpClient.lock.RUnlock()
+
+	return chain(interceptors, httpClient.client.Do)(httpRequest)
+}
+
+// 请求的完整地址：不是绝对地址时拼接基础地址
+func (httpClient *HttpClient) fullUrl(requestUrl string) string {
+	if httpClient.baseUrl == "" || strings.Contains(requestUrl, "://") {
+		return requestUrl
+	}
+	if requestUrl == "" {
+		return httpClient.baseUrl
+	}
+	return strings.TrimSuffix(httpClient.baseUrl, "/") + "/" + strings.TrimPrefix(requestUrl, "/")
+}
+
+func createTransport(clientConfig config.HttpClientConfig) (*http.Transport, error) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   durationOrDefault(clientConfig.DialTimeout, 30*time.Second),
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          intOrDefault(clientConfig.MaxIdleConns, MaxIdleConns),
+		MaxIdleConnsPerHost:   intOrDefault(clientConfig.MaxIdleConnsPerHost, MaxIdleConnsPerHost),
+		MaxConnsPerHost:       clientConfig.MaxConnsPerHost,
+		IdleConnTimeout:       durationOrDefault(clientConfig.IdleConnTimeout, time.Duration(IdleConnTimeout)*time.Second),
+		ResponseHeaderTimeout: time.Duration(clientConfig.ResponseHeaderTimeout) * time.Millisecond,
+	}
+
+	if clientConfig.Proxy != "" {
+		proxyUrl, err := url.Parse(clientConfig.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+
+	tlsConfig, err := createTlsConfig(clientConfig.Tls)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+func createTlsConfig(tlsConfig config.HttpTlsConfig) (*tls.Config, error) {
+	if tlsConfig.CaFile == "" && tlsConfig.CertFile == "" && !tlsConfig.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	result := &tls.Config{InsecureSkipVerify: tlsConfig.InsecureSkipVerify}
+	if tlsConfig.CaFile != "" {
+		caPem, err := ioutil.ReadFile(tlsConfig.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPem) {
+			return nil, &NetError{ErrMsg: "no certificate found in ca file: " + tlsConfig.CaFile}
+		}
+		result.RootCAs = pool
+	}
+	if tlsConfig.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(tlsConfig.CertFile, tlsConfig.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		result.Certificates = []tls.Certificate{certificate}
+	}
+	return result, nil
+}
+
+func durationOrDefault(millis int, defaultValue time.Duration) time.Duration {
+	if millis > 0 {
+		return time.Duration(millis) * time.Millisecond
+	}
+	return defaultValue
+}
+
+func intOrDefault(value, defaultValue int) int {
+	if value > 0 {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,189 @@
+package http
+
+import (
+	"github.com/isyscore/gole/config"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+func init() {
+	config.LoadConfig()
+	loadConfig()
+	config.AddChangeListener("base.http.limits", func(keys []string) {
+		loadLimits()
+	})
+}
+
+// 读取base.http的配置
+func loadConfig() {
+	if config.GetValue("base.http") == nil {
+		return
+	}
+	if err := config.GetValueObject("base.http", &config.HttpCfg); err != nil {
+		getLogger().Errorf("read base.http config err, %v", err.Error())
+		return
+	}
+
+	if config.HttpCfg.Log.LoggerName != "" {
+		SetLoggerName(config.HttpCfg.Log.LoggerName)
+	}
+	SetLogOptions(logOptionsOfConfig(config.HttpCfg.Log))
+	if config.HttpCfg.Retry.Enable {
+		SetRetryPolicy(retryPolicyOfConfig(config.HttpCfg.Retry))
+	}
+	if config.HttpCfg.CircuitBreaker.Enable {
+		SetCircuitBreakerPolicy(circuitBreakerPolicyOfConfig(config.HttpCfg.CircuitBreaker))
+	}
+	if config.HttpCfg.LoadBalancer != "" {
+		SetBalancer(BalancerOf(config.HttpCfg.LoadBalancer))
+	}
+	SetOutlierPolicy(outlierPolicyOfConfig(config.HttpCfg.OutlierDetection))
+	if config.HttpCfg.Cache.Enable {
+		cache, err := cacheOfConfig(config.HttpCfg.Cache)
+		if err != nil {
+			getLogger().Errorf("create http cache err, %v", err.Error())
+		} else {
+			configCache = cache
+			AddInterceptor(cache.Interceptor())
+		}
+	}
+	for name, clientConfig := range config.HttpCfg.Clients {
+		httpClient, err := NewClientWithConfig(clientConfig)
+		if err != nil {
+			getLogger().Errorf("create http client %v err, %v", name, err.Error())
+			continue
+		}
+		RegisterClient(name, httpClient)
+	}
+	applyLimits(config.HttpCfg.Limits)
+}
+
+// 配置中的限流，配置变更时删除不再配置的限流
+var configLimitKeys = map[string]bool{}
+var configLimitLock sync.Mutex
+
+// 重新读取base.http.limits的配置
+func loadLimits() {
+	var httpConfig config.HttpConfig
+	if err := config.GetValueObject("base.http", &httpConfig); err != nil {
+		getLogger().Errorf("read base.http.limits config err, %v", err.Error())
+		return
+	}
+	config.HttpCfg.Limits = httpConfig.Limits
+	applyLimits(httpConfig.Limits)
+}
+
+func applyLimits(limitConfigs []config.HttpLimitConfig) {
+	configLimitLock.Lock()
+	defer configLimitLock.Unlock()
+
+	keys := map[string]bool{}
+	for _, limitConfig := range limitConfigs {
+		policy := &LimitPolicy{Rate: limitConfig.Rate, Burst: limitConfig.Burst, MaxInFlight: limitConfig.MaxInFlight, FailFast: limitConfig.FailFast}
+		if limitConfig.Client != "" {
+			SetClientLimit(limitConfig.Client, policy)
+			keys["client:"+limitConfig.Client] = true
+		} else if limitConfig.Host != "" {
+			SetHostLimit(limitConfig.Host, policy)
+			keys[limitConfig.Host] = true
+		}
+	}
+	for key := range configLimitKeys {
+		if !keys[key] {
+			setLimit(key, nil)
+		}
+	}
+	configLimitKeys = keys
+}
+
+func retryPolicyOfConfig(retryConfig config.HttpRetryConfig) *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	if retryConfig.MaxAttempts > 0 {
+		policy.MaxAttempts = retryConfig.MaxAttempts
+	}
+	if retryConfig.InitialBackoff > 0 {
+		policy.InitialBackoff = time.Duration(retryConfig.InitialBackoff) * time.Millisecond
+	}
+	if retryConfig.MaxBackoff > 0 {
+		policy.MaxBackoff = time.Duration(retryConfig.MaxBackoff) * time.Millisecond
+	}
+	if retryConfig.Jitter > 0 {
+		policy.Jitter = retryConfig.Jitter
+	}
+	if len(retryConfig.StatusCodes) > 0 {
+		policy.StatusCodes = retryConfig.StatusCodes
+	}
+	policy.RetryNonIdempotent = retryConfig.RetryNonIdempotent
+	return policy
+}
+
+func circuitBreakerPolicyOfConfig(breakerConfig config.HttpCircuitBreakerConfig) *CircuitBreakerPolicy {
+	policy := DefaultCircuitBreakerPolicy()
+	if breakerConfig.FailureThreshold > 0 {
+		policy.FailureThreshold = breakerConfig.FailureThreshold
+	}
+	if breakerConfig.OpenTimeout > 0 {
+		policy.OpenTimeout = time.Duration(breakerConfig.OpenTimeout) * time.Millisecond
+	}
+	if breakerConfig.HalfOpenRequests > 0 {
+		policy.HalfOpenRequests = breakerConfig.HalfOpenRequests
+	}
+	return policy
+}
+
+func outlierPolicyOfConfig(outlierConfig config.HttpOutlierDetectionConfig) *OutlierPolicy {
+	if outlierConfig.ConsecutiveFailures < 0 {
+		return nil
+	}
+	policy := DefaultOutlierPolicy()
+	if outlierConfig.ConsecutiveFailures > 0 {
+		policy.ConsecutiveFailures = outlierConfig.ConsecutiveFailures
+	}
+	if outlierConfig.EjectionTime > 0 {
+		policy.EjectionTime = time.Duration(outlierConfig.EjectionTime) * time.Millisecond
+	}
+	if outlierConfig.MaxEjectionPercent > 0 {
+		policy.MaxEjectionPercent = outlierConfig.MaxEjectionPercent
+	}
+	return policy
+}
+
+func cacheOfConfig(cacheConfig config.HttpCacheConfig) (*Cache, error) {
+	var store CacheStore
+	if cacheConfig.Store == "redis" {
+		keyPrefix := cacheConfig.KeyPrefix
+		if keyPrefix == "" {
+			keyPrefix = "gole:http:cache:"
+		}
+		redisStore, err := NewRedisCacheStore(keyPrefix)
+		if err != nil {
+			return nil, err
+		}
+		store = redisStore
+	} else {
+		store = NewMemoryCacheStore(intOrDefault(cacheConfig.Capacity, 1000))
+	}
+
+	cache := NewCache(store)
+	if cacheConfig.Retention > 0 {
+		cache.Retention = time.Duration(cacheConfig.Retention) * time.Millisecond
+	}
+	return cache, nil
+}
+
+func logOptionsOfConfig(logConfig config.HttpLogConfig) *LogOptions {
+	options := DefaultLogOptions()
+	if level, err := logrus.ParseLevel(logConfig.Level); err == nil {
+		options.Level = level
+	}
+	options.LogBody = logConfig.LogBody
+	if logConfig.MaxBodySize > 0 {
+		options.MaxBodySize = logConfig.MaxBodySize
+	}
+	if len(logConfig.RedactHeaders) > 0 {
+		options.RedactHeaders = logConfig.RedactHeaders
+	}
+	options.RedactFields = logConfig.RedactFields
+	return options
+}
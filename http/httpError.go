@@ -0,0 +1,58 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// NetError 其他的错误
+type NetError struct {
+	ErrMsg string
+}
+
+func (error *NetError) Error() string {
+	return error.ErrMsg
+}
+
+// TransportError 网络错误，没有拿到响应，比如连接失败、超时、取消
+type TransportError struct {
+	Method string
+	URL    string
+	Err    error
+}
+
+func (error *TransportError) Error() string {
+	return "Error sending request, url: " + error.URL + ", err: " + error.Err.Error()
+}
+
+func (error *TransportError) Unwrap() error {
+	return error.Err
+}
+
+// StatusError 响应的状态码不是2xx
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+func (error *StatusError) Error() string {
+	return "remote error, url: " + error.URL + ", code " + strconv.Itoa(error.StatusCode) + ", message: " + string(error.Body)
+}
+
+// DecodeError 响应体解析失败
+type DecodeError struct {
+	URL  string
+	Body []byte
+	Err  error
+}
+
+func (error *DecodeError) Error() string {
+	return "decode response err, url: " + error.URL + ", err: " + error.Err.Error()
+}
+
+func (error *DecodeError) Unwrap() error {
+	return error.Err
+}
@@ -1,19 +1,16 @@
 package http
 
 import (
-	"encoding/json"
-	"github.com/lunny/log"
-	"io"
-	"io/ioutil"
+	"context"
+	"github.com/isyscore/gole/trace"
 	"net"
 	"net/http"
-	"reflect"
-	"strconv"
-	"strings"
+	"net/url"
+	"regexp"
 	"time"
 )
 
-var httpClient = createHTTPClient()
+var defaultClient = NewClient(nil)
 
 const (
 	MaxIdleConns        int = 100
@@ -21,13 +18,10 @@ const (
 	IdleConnTimeout     int = 90
 )
 
-type NetError struct {
-	ErrMsg string
-}
+// DefaultTimeout 调用的默认超时时间，ctx中没有设置超时和截止时间时使用
+var DefaultTimeout = 20 * time.Second
 
-func (error *NetError) Error() string {
-	return error.ErrMsg
-}
+type timeoutKey struct{}
 
 type StandardResponse struct {
 	Code    interface{} `json:"code"`
@@ -48,14 +42,31 @@ func createHTTPClient() *http.Client {
 			MaxIdleConnsPerHost: MaxIdleConnsPerHost,
 			IdleConnTimeout:     time.Duration(IdleConnTimeout) * time.Second,
 		},
-
-		Timeout: 20 * time.Second,
 	}
 	return client
 }
 
 func SetHttpClient(httpClientOuter *http.Client) {
-	httpClient = httpClientOuter
+	defaultClient.client = httpClientOuter
+}
+
+// WithTimeout 设置单次调用的超时时间，超时在调用时才开始计算，不需要cancel
+func WithTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, timeoutKey{}, timeout)
+}
+
+// 调用使用的ctx：优先单次调用的超时，其次ctx本身的截止时间，都没有则使用默认超时
+func callContext(ctx context.Context, defaultTimeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout, ok := ctx.Value(timeoutKey{}).(time.Duration); ok && timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	if _, ok := ctx.Deadline(); !ok && defaultTimeout > 0 {
+		return context.WithTimeout(ctx, defaultTimeout)
+	}
+	return context.WithCancel(ctx)
 }
 
 // ------------------ get ------------------
@@ -69,31 +80,25 @@ func GetSimpleOfStandard(url string) ([]byte, error) {
 }
 
 func Get(url string, header http.Header, parameterMap map[string]string) ([]byte, error) {
-	httpRequest, err := http.NewRequest("GET", urlWithParameter(url, parameterMap), nil)
-	if err != nil {
-		log.Errorf("NewRequest err, %v", err.Error())
-		return nil, err
-	}
-
-	if header != nil {
-		httpRequest.Header = header
-	}
+	return GetCtx(context.Background(), url, header, parameterMap)
+}
 
-	return call(httpRequest, url)
+func GetCtx(ctx context.Context, url string, header http.Header, parameterMap map[string]string) ([]byte, error) {
+	return bodyOf(New().Method(http.MethodGet).URL(url).Headers(header).Queries(parameterMap).Do(ctx))
 }
 
 func GetOfStandard(url string, header http.Header, parameterMap map[string]string) ([]byte, error) {
-	httpRequest, err := http.NewRequest("GET", urlWithParameter(url, parameterMap), nil)
-	if err != nil {
-		log.Errorf("NewRequest err, %v", err.Error())
-		return nil, err
-	}
+	return GetOfStandardCtx(context.Background(), url, header, parameterMap)
+}
 
-	if header != nil {
-		httpRequest.Header = header
-	}
+func GetOfStandardCtx(ctx context.Context, url string, header http.Header, parameterMap map[string]string) ([]byte, error) {
+	return parseStandard(bodyOf(New().Method(http.MethodGet).URL(url).Headers(header).Queries(parameterMap).Do(ctx)))
+}
 
-	return callToStandard(httpRequest, url)
+// GetOfStandardInto 标准响应的data解析到target中，target需要为指针
+func GetOfStandardInto(url string, target interface{}) error {
+	_, err := New().Method(http.MethodGet).URL(url).IntoStandard(target).Do(context.Background())
+	return err
 }
 
 // ------------------ head ------------------
@@ -103,17 +108,17 @@ func HeadSimple(url string) error {
 }
 
 func Head(url string, header http.Header, parameterMap map[string]string) error {
-	httpRequest, err := http.NewRequest("GET", urlWithParameter(url, parameterMap), nil)
-	if err != nil {
-		log.Errorf("NewRequest err, %v", err.Error())
-		return err
-	}
+	return HeadCtx(context.Background(), url, header, parameterMap)
+}
 
-	if header != nil {
-		httpRequest.Header = header
-	}
+func HeadCtx(ctx context.Context, url string, header http.Header, parameterMap map[string]string) error {
+	_, err := HeadOfResponse(ctx, url, header, parameterMap)
+	return err
+}
 
-	return callIgnoreReturn(httpRequest, url)
+// HeadOfResponse 发送HEAD请求，返回状态码、响应头、内容长度，最后修改时间通过Response.LastModified获取
+func HeadOfResponse(ctx context.Context, url string, header http.Header, parameterMap map[string]string) (*Response, error) {
+	return Do(ctx, http.MethodHead, url, header, parameterMap, nil)
 }
 
 // ------------------ post ------------------
@@ -127,35 +132,25 @@ func PostSimpleOfStandard(url string, body interface{}) ([]byte, error) {
 }
 
 func Post(url string, header http.Header, parameterMap map[string]string, body interface{}) ([]byte, error) {
-	bytes, _ := json.Marshal(body)
-	payload := strings.NewReader(string(bytes))
-	httpRequest, err := http.NewRequest("POST", urlWithParameter(url, parameterMap), payload)
-	if err != nil {
-		log.Errorf("NewRequest err, %v", err.Error())
-		return nil, err
-	}
+	return PostCtx(context.Background(), url, header, parameterMap, body)
+}
 
-	if header != nil {
-		httpRequest.Header = header
-	}
-	httpRequest.Header.Add("Content-Type", "application/json")
-	return call(httpRequest, url)
+func PostCtx(ctx context.Context, url string, header http.Header, parameterMap map[string]string, body interface{}) ([]byte, error) {
+	return bodyOf(New().Method(http.MethodPost).URL(url).Headers(header).Queries(parameterMap).JSON(body).Do(ctx))
 }
 
 func PostOfStandard(url string, header http.Header, parameterMap map[string]string, body interface{}) ([]byte, error) {
-	bytes, _ := json.Marshal(body)
-	payload := strings.NewReader(string(bytes))
-	httpRequest, err := http.NewRequest("POST", urlWithParameter(url, parameterMap), payload)
-	if err != nil {
-		log.Errorf("NewRequest err, %v", err.Error())
-		return nil, err
-	}
+	return PostOfStandardCtx(context.Background(), url, header, parameterMap, body)
+}
 
-	if header != nil {
-		httpRequest.Header = header
-	}
-	httpRequest.Header.Add("Content-Type", "application/json")
-	return callToStandard(httpRequest, url)
+func PostOfStandardCtx(ctx context.Context, url string, header http.Header, parameterMap map[string]string, body interface{}) ([]byte, error) {
+	return parseStandard(bodyOf(New().Method(http.MethodPost).URL(url).Headers(header).Queries(parameterMap).JSON(body).Do(ctx)))
+}
+
+// PostOfStandardInto 标准响应的data解析到target中，target需要为指针
+func PostOfStandardInto(url string, body interface{}, target interface{}) error {
+	_, err := New().Method(http.MethodPost).URL(url).JSON(body).IntoStandard(target).Do(context.Background())
+	return err
 }
 
 // ------------------ put ------------------
@@ -169,233 +164,182 @@ func PutSimpleOfStandard(url string, body interface{}) ([]byte, error) {
 }
 
 func Put(url string, header http.Header, parameterMap map[string]string, body interface{}) ([]byte, error) {
-	bytes, _ := json.Marshal(body)
-	payload := strings.NewReader(string(bytes))
-	httpRequest, err := http.NewRequest("PUT", urlWithParameter(url, parameterMap), payload)
-	if err != nil {
-		log.Errorf("NewRequest err, %v", err.Error())
-		return nil, err
-	}
+	return PutCtx(context.Background(), url, header, parameterMap, body)
+}
 
-	if header != nil {
-		httpRequest.Header = header
-	}
-	httpRequest.Header.Add("Content-Type", "application/json")
-	return call(httpRequest, url)
+func PutCtx(ctx context.Context, url string, header http.Header, parameterMap map[string]string, body interface{}) ([]byte, error) {
+	return bodyOf(New().Method(http.MethodPut).URL(url).Headers(header).Queries(parameterMap).JSON(body).Do(ctx))
 }
 
 func PutOfStandard(url string, header http.Header, parameterMap map[string]string, body interface{}) ([]byte, error) {
-	bytes, _ := json.Marshal(body)
-	payload := strings.NewReader(string(bytes))
-	httpRequest, err := http.NewRequest("PUT", urlWithParameter(url, parameterMap), payload)
-	if err != nil {
-		log.Errorf("NewRequest err, %v", err.Error())
-		return nil, err
-	}
+	return PutOfStandardCtx(context.Background(), url, header, parameterMap, body)
+}
 
-	if header != nil {
-		httpRequest.Header = header
-	}
-	httpRequest.Header.Add("Content-Type", "application/json")
-	return callToStandard(httpRequest, url)
+func PutOfStandardCtx(ctx context.Context, url string, header http.Header, parameterMap map[string]string, body interface{}) ([]byte, error) {
+	return parseStandard(bodyOf(New().Method(http.MethodPut).URL(url).Headers(header).Queries(parameterMap).JSON(body).Do(ctx)))
+}
+
+// PutOfStandardInto 标准响应的data解析到target中，target需要为指针
+func PutOfStandardInto(url string, body interface{}, target interface{}) error {
+	_, err := New().Method(http.MethodPut).URL(url).JSON(body).IntoStandard(target).Do(context.Background())
+	return err
 }
 
 // ------------------ delete ------------------
 
 func DeleteSimple(url string) ([]byte, error) {
-	return Get(url, nil, nil)
+	return Delete(url, nil, nil)
 }
 
 func DeleteSimpleOfStandard(url string) ([]byte, error) {
-	return GetOfStandard(url, nil, nil)
+	return DeleteOfStandard(url, nil, nil)
 }
 
 func Delete(url string, header http.Header, parameterMap map[string]string) ([]byte, error) {
-	httpRequest, err := http.NewRequest("DELETE", urlWithParameter(url, parameterMap), nil)
-	if err != nil {
-		log.Errorf("NewRequest err, %v", err.Error())
-		return nil, err
-	}
-
-	if header != nil {
-		httpRequest.Header = header
-	}
+	return DeleteCtx(context.Background(), url, header, parameterMap)
+}
 
-	return call(httpRequest, url)
+func DeleteCtx(ctx context.Context, url string, header http.Header, parameterMap map[string]string) ([]byte, error) {
+	return bodyOf(New().Method(http.MethodDelete).URL(url).Headers(header).Queries(parameterMap).Do(ctx))
 }
 
 func DeleteOfStandard(url string, header http.Header, parameterMap map[string]string) ([]byte, error) {
-	httpRequest, err := http.NewRequest("DELETE", urlWithParameter(url, parameterMap), nil)
-	if err != nil {
-		log.Errorf("NewRequest err, %v", err.Error())
-		return nil, err
-	}
+	return DeleteOfStandardCtx(context.Background(), url, header, parameterMap)
+}
 
-	if header != nil {
-		httpRequest.Header = header
-	}
+func DeleteOfStandardCtx(ctx context.Context, url string, header http.Header, parameterMap map[string]string) ([]byte, error) {
+	return parseStandard(bodyOf(New().Method(http.MethodDelete).URL(url).Headers(header).Queries(parameterMap).Do(ctx)))
+}
 
-	return callToStandard(httpRequest, url)
+// DeleteOfStandardInto 标准响应的data解析到target中，target需要为指针
+func DeleteOfStandardInto(url string, target interface{}) error {
+	_, err := New().Method(http.MethodDelete).URL(url).IntoStandard(target).Do(context.Background())
+	return err
 }
 
 // ------------------ patch ------------------
 
 func PatchSimple(url string, body interface{}) ([]byte, error) {
-	return Post(url, nil, nil, body)
+	return Patch(url, nil, nil, body)
 }
 
 func PatchSimpleOfStandard(url string, body interface{}) ([]byte, error) {
-	return PostOfStandard(url, nil, nil, body)
+	return PatchOfStandard(url, nil, nil, body)
 }
 
 func Patch(url string, header http.Header, parameterMap map[string]string, body interface{}) ([]byte, error) {
-	bytes, _ := json.Marshal(body)
-	payload := strings.NewReader(string(bytes))
-	httpRequest, err := http.NewRequest("PATCH", urlWithParameter(url, parameterMap), payload)
-	if err != nil {
-		log.Errorf("NewRequest err, %v", err.Error())
-		return nil, err
-	}
+	return PatchCtx(context.Background(), url, header, parameterMap, body)
+}
 
-	if header != nil {
-		httpRequest.Header = header
-	}
-	httpRequest.Header.Add("Content-Type", "application/json")
-	return call(httpRequest, url)
+func PatchCtx(ctx context.Context, url string, header http.Header, parameterMap map[string]string, body interface{}) ([]byte, error) {
+	return bodyOf(New().Method(http.MethodPatch).URL(url).Headers(header).Queries(parameterMap).JSON(body).Do(ctx))
 }
 
 func PatchOfStandard(url string, header http.Header, parameterMap map[string]string, body interface{}) ([]byte, error) {
-	bytes, _ := json.Marshal(body)
-	payload := strings.NewReader(string(bytes))
-	httpRequest, err := http.NewRequest("PATCH", urlWithParameter(url, parameterMap), payload)
-	if err != nil {
-		log.Errorf("NewRequest err, %v", err.Error())
-		return nil, err
-	}
-
-	if header != nil {
-		httpRequest.Header = header
-	}
-	httpRequest.Header.Add("Content-Type", "application/json")
-	return callToStandard(httpRequest, url)
-}
-
-func call(httpRequest *http.Request, url string) ([]byte, error) {
-	httpResponse, err := httpClient.Do(httpRequest)
-	if err != nil && httpResponse == nil {
-		log.Printf("Error sending request to API endpoint. %+v", err)
-		return nil, &NetError{ErrMsg: "Error sending request, url: " + url + ", err" + err.Error()}
-	} else {
-		if httpResponse == nil {
-			log.Printf("httpResponse is nil")
-			return nil, nil
-		}
-
-		defer func(Body io.ReadCloser) {
-			err := Body.Close()
-			if err != nil {
-				log.Infof("Body close err. %+v", err.Error())
-			}
-		}(httpResponse.Body)
-
-		code := httpResponse.StatusCode
-		if code != http.StatusOK {
-			body, _ := ioutil.ReadAll(httpResponse.Body)
-			return nil, &NetError{ErrMsg: "remote error, url: " + url + ", code " + strconv.Itoa(code) + ", message: " + string(body)}
-		}
+	return PatchOfStandardCtx(context.Background(), url, header, parameterMap, body)
+}
 
-		// We have seen inconsistencies even when we get 200 OK response
-		body, err := ioutil.ReadAll(httpResponse.Body)
-		if err != nil {
-			log.Printf("Couldn't parse response body %+v", err)
-			return nil, &NetError{ErrMsg: "Couldn't parse response body, err: " + err.Error()}
-		}
+func PatchOfStandardCtx(ctx context.Context, url string, header http.Header, parameterMap map[string]string, body interface{}) ([]byte, error) {
+	return parseStandard(bodyOf(New().Method(http.MethodPatch).URL(url).Headers(header).Queries(parameterMap).JSON(body).Do(ctx)))
+}
 
-		return body, nil
-	}
+// PatchOfStandardInto 标准响应的data解析到target中，target需要为指针
+func PatchOfStandardInto(url string, body interface{}, target interface{}) error {
+	_, err := New().Method(http.MethodPatch).URL(url).JSON(body).IntoStandard(target).Do(context.Background())
+	return err
 }
 
-// ------------------ trace ------------------
 // ------------------ options ------------------
-// 暂时先不处理
-
-func callIgnoreReturn(httpRequest *http.Request, url string) error {
-	httpResponse, err := httpClient.Do(httpRequest)
-	if err != nil && httpResponse == nil {
-		log.Printf("Error sending request to API endpoint. %+v", err)
-		return &NetError{ErrMsg: "Error sending request, url: " + url + ", err" + err.Error()}
-	} else {
-		if httpResponse == nil {
-			log.Printf("httpResponse is nil")
-			return nil
-		}
 
-		defer func(Body io.ReadCloser) {
-			err := Body.Close()
-			if err != nil {
-				log.Infof("Body close err. %+v", err.Error())
-			}
-		}(httpResponse.Body)
-
-		code := httpResponse.StatusCode
-		if code != http.StatusOK {
-			body, _ := ioutil.ReadAll(httpResponse.Body)
-			return &NetError{ErrMsg: "remote error, url: " + url + ", code " + strconv.Itoa(code) + ", message: " + string(body)}
-		}
+func OptionsSimple(url string) (*Response, error) {
+	return Options(url, nil, nil)
+}
 
-		return nil
-	}
+func Options(url string, header http.Header, parameterMap map[string]string) (*Response, error) {
+	return OptionsCtx(context.Background(), url, header, parameterMap)
 }
 
-func callToStandard(httpRequest *http.Request, url string) ([]byte, error) {
-	return parseStandard(call(httpRequest, url))
+// OptionsCtx 发送OPTIONS请求，允许的方法在响应头Allow中
+func OptionsCtx(ctx context.Context, url string, header http.Header, parameterMap map[string]string) (*Response, error) {
+	return Do(ctx, http.MethodOptions, url, header, parameterMap, nil)
 }
 
-func parseStandard(responseResult []byte, errs error) ([]byte, error) {
-	if errs != nil {
-		return nil, errs
-	}
-	var standRsp StandardResponse
-	err := json.Unmarshal(responseResult, &standRsp)
-	if err != nil {
-		return nil, err
+// ------------------ any method ------------------
+
+// Do 发送任意方法的请求，body不为nil时序列化为json；非2xx的响应同时返回响应和StatusError
+func Do(ctx context.Context, method, url string, header http.Header, parameterMap map[string]string, body interface{}) (*Response, error) {
+	request := New().Method(method).URL(url).Headers(header).Queries(parameterMap)
+	if body != nil {
+		request.JSON(body)
 	}
+	return request.Do(ctx)
+}
 
-	if standRsp.Code == nil {
-		return nil, &NetError{ErrMsg: "code is nil"}
+// ------------------ trace ------------------
+
+// ctx中有span时（比如web.TraceHandler处理的请求）创建client span，并将traceparent、tracestate注入到请求头中
+func startClientSpan(httpRequest *http.Request) (*http.Request, *trace.Span) {
+	if trace.FromContext(httpRequest.Context()) == nil {
+		return httpRequest, nil
 	}
+	ctx, span := trace.StartSpan(httpRequest.Context(), httpRequest.Method+" "+httpRequest.URL.Host, trace.SpanKindClient)
+	span.SetAttribute("http.method", httpRequest.Method)
+	span.SetAttribute("http.url", httpRequest.URL.String())
+	httpRequest = httpRequest.WithContext(ctx)
+	trace.Inject(ctx, httpRequest.Header)
+	return httpRequest, span
+}
 
-	// 判断业务的失败信息
-	codeKind := reflect.ValueOf(standRsp.Code).Kind()
-	if codeKind == reflect.String {
-		if standRsp.Code != "success" {
-			return nil, &NetError{ErrMsg: "remote err, bizCode=" + standRsp.Code.(string) + ", message=" + standRsp.Message}
-		}
-	} else if codeKind == reflect.Int || codeKind == reflect.Int8 || codeKind == reflect.Int16 || codeKind == reflect.Int32 || codeKind == reflect.Int64 {
-		if standRsp.Code != 0 && standRsp.Code != 200 {
-			return nil, &NetError{ErrMsg: "remote err, bizCode=" + standRsp.Code.(string) + ", message=" + standRsp.Message}
-		}
+func endClientSpan(span *trace.Span, statusCode int, err error) {
+	if span == nil {
+		return
 	}
+	if statusCode > 0 {
+		span.SetAttribute("http.status_code", statusCode)
+	}
+	span.SetError(err)
+	span.End()
+}
 
-	data, err := json.Marshal(standRsp.Data)
-	if nil != err {
+func bodyOf(response *Response, err error) ([]byte, error) {
+	if err != nil {
 		return nil, err
 	}
-
-	return data, nil
+	return response.Body, nil
 }
 
-func urlWithParameter(url string, parameterMap map[string]string) string {
-	if parameterMap == nil || len(parameterMap) == 0 {
-		return url
+// UrlWithQuery 在url上追加参数：url中已有的参数会保留，同名的参数追加为多个值；参数按照key排序，顺序稳定可用于签名
+func UrlWithQuery(rawUrl string, query url.Values) (string, error) {
+	if len(query) == 0 {
+		return rawUrl, nil
 	}
 
-	url += "?"
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", err
+	}
 
-	var parameters []string
-	for key, value := range parameterMap {
-		parameters = append(parameters, key+"="+value)
+	values := parsedUrl.Query()
+	for key, valueList := range query {
+		values[key] = append(values[key], valueList...)
 	}
+	parsedUrl.RawQuery = values.Encode()
+	return parsedUrl.String(), nil
+}
+
+var pathParamRegex = regexp.MustCompile(`\{([^{}/]+)}`)
 
-	return url + strings.Join(parameters, "&")
+// UrlWithPathParam 替换url路径中的{name}占位符，值会被转义；有占位符没有对应的值则报错
+func UrlWithPathParam(rawUrl string, pathParams map[string]string) (string, error) {
+	var err error
+	result := pathParamRegex.ReplaceAllStringFunc(rawUrl, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		value, exist := pathParams[name]
+		if !exist {
+			err = &NetError{ErrMsg: "path param is not set: " + name + ", url: " + rawUrl}
+			return placeholder
+		}
+		return url.PathEscape(value)
+	})
+	return result, err
 }
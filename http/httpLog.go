@@ -0,0 +1,257 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/isyscore/gole/log"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogOptions 调用日志的配置
+type LogOptions struct {
+	// 调用日志的最低级别，成功的调用为debug，4xx和重试为warn，5xx和网络错误为error
+	Level logrus.Level
+	// 是否记录请求头、请求体和响应体
+	LogBody bool
+	// 记录的请求体和响应体的最大长度，超过则截断
+	MaxBodySize int
+	// 替换为***的请求头
+	RedactHeaders []string
+	// 替换为***的json字段和url参数
+	RedactFields []string
+}
+
+// 替换敏感内容的值
+const redacted = "***"
+
+var logOptions = DefaultLogOptions()
+var logOptionsLock sync.RWMutex
+var loggerName = "gole-http"
+var customLogger *logrus.Logger
+var fileLogger *logrus.Logger
+var loggerLock sync.Mutex
+var consoleLogger = newConsoleLogger()
+
+// DefaultLogOptions 默认的调用日志配置：info级别，不记录请求体和响应体
+func DefaultLogOptions() *LogOptions {
+	return &LogOptions{Level: logrus.InfoLevel, MaxBodySize: 1024, RedactHeaders: []string{"Authorization", "Cookie", "Set-Cookie"}}
+}
+
+// SetLogOptions 设置调用日志的配置，nil则使用默认配置
+func SetLogOptions(options *LogOptions) {
+	if options == nil {
+		options = DefaultLogOptions()
+	}
+	logOptionsLock.Lock()
+	defer logOptionsLock.Unlock()
+	logOptions = options
+}
+
+func getLogOptions() *LogOptions {
+	logOptionsLock.RLock()
+	defer logOptionsLock.RUnlock()
+	return logOptions
+}
+
+// SetLogger 设置日志，nil则使用log.GetLogger获取的日志
+func SetLogger(logger *logrus.Logger) {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	customLogger = logger
+}
+
+// SetLoggerName 设置通过log.GetLogger获取的日志的名字，默认gole-http
+func SetLoggerName(name string) {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	loggerName = name
+	fileLogger = nil
+}
+
+// 日志文件没有配置时（log.GetLogger返回nil）输出到控制台，格式和日志文件相同
+func getLogger() *logrus.Logger {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+
+	if customLogger != nil {
+		return customLogger
+	}
+	if fileLogger == nil {
+		fileLogger = log.GetLogger(loggerName)
+	}
+	if fileLogger != nil {
+		return fileLogger
+	}
+	return consoleLogger
+}
+
+func newConsoleLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+	logger.SetLevel(logrus.DebugLevel)
+	logger.Formatter = &log.StandardFormatter{}
+	logger.AddHook(&log.TraceHook{})
+	return logger
+}
+
+// 记录一次请求：方法、url、状态码、耗时和第几次尝试，按照结果确定级别
+func logCall(httpRequest *http.Request, response *Response, err error, latency time.Duration, attempt int) {
+	options := getLogOptions()
+	level := callLevel(response, err)
+	if level > options.Level {
+		return
+	}
+	logger := getLogger()
+	if !logger.IsLevelEnabled(level) {
+		return
+	}
+
+	fields := logrus.Fields{
+		"method":  httpRequest.Method,
+		"url":     options.redactUrl(httpRequest),
+		"latency": latency,
+		"attempt": attempt,
+	}
+	if response != nil {
+		fields["status"] = response.StatusCode
+	}
+	if err != nil {
+		fields["err"] = err.Error()
+	}
+	if options.LogBody {
+		fields["requestHeader"] = options.redactHeader(httpRequest.Header)
+		if httpRequest.GetBody != nil {
+			if body, err := httpRequest.GetBody(); err == nil {
+				content, _ := ioutil.ReadAll(body)
+				_ = body.Close()
+				fields["requestBody"] = options.redactBody(httpRequest.Header.Get("Content-Type"), content)
+			}
+		}
+		if response != nil {
+			fields["responseBody"] = options.redactBody(response.Header.Get("Content-Type"), response.Body)
+		}
+	}
+
+	if err != nil || level <= logrus.WarnLevel {
+		logger.WithContext(httpRequest.Context()).WithFields(fields).Log(level, "http request failed")
+	} else {
+		logger.WithContext(httpRequest.Context()).WithFields(fields).Log(level, "http request")
+	}
+}
+
+// 重试前记录
+func logRetry(httpRequest *http.Request, attempt int, backoff time.Duration) {
+	options := getLogOptions()
+	if logrus.WarnLevel > options.Level {
+		return
+	}
+	getLogger().WithContext(httpRequest.Context()).WithFields(logrus.Fields{
+		"method":  httpRequest.Method,
+		"url":     options.redactUrl(httpRequest),
+		"attempt": attempt,
+		"backoff": backoff,
+	}).Warn("retry request")
+}
+
+// 调用方取消、熔断和限流为warn，其他的错误和5xx为error，4xx为warn，成功为debug
+func callLevel(response *Response, err error) logrus.Level {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return logrus.WarnLevel
+		}
+		switch err.(type) {
+		case *CircuitOpenError, *LimitError:
+			return logrus.WarnLevel
+		}
+		return logrus.ErrorLevel
+	}
+	if response.StatusCode >= http.StatusInternalServerError {
+		return logrus.ErrorLevel
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		return logrus.WarnLevel
+	}
+	return logrus.DebugLevel
+}
+
+func (options *LogOptions) redactField(name string) bool {
+	for _, field := range options.RedactFields {
+		if strings.EqualFold(field, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (options *LogOptions) redactUrl(httpRequest *http.Request) string {
+	if len(options.RedactFields) == 0 || httpRequest.URL.RawQuery == "" {
+		return httpRequest.URL.String()
+	}
+	requestUrl := *httpRequest.URL
+	requestUrl.RawQuery = options.redactValues(requestUrl.Query()).Encode()
+	return requestUrl.String()
+}
+
+func (options *LogOptions) redactValues(values url.Values) url.Values {
+	for key := range values {
+		if options.redactField(key) {
+			values.Set(key, redacted)
+		}
+	}
+	return values
+}
+
+func (options *LogOptions) redactHeader(header http.Header) http.Header {
+	result := header.Clone()
+	for _, name := range options.RedactHeaders {
+		if result.Get(name) != "" {
+			result.Set(name, redacted)
+		}
+	}
+	return result
+}
+
+// json和表单的请求体替换敏感字段，其他的原样记录；超过MaxBodySize截断
+func (options *LogOptions) redactBody(contentType string, body []byte) string {
+	content := string(body)
+	var value interface{}
+	if len(options.RedactFields) > 0 && strings.HasPrefix(strings.ToLower(contentType), ContentTypeForm) {
+		// 解析失败的参数不记录，避免原样输出敏感内容
+		values, _ := url.ParseQuery(content)
+		content = options.redactValues(values).Encode()
+	} else if len(options.RedactFields) > 0 && json.Unmarshal(body, &value) == nil {
+		if data, err := json.Marshal(options.redactValue(value)); err == nil {
+			content = string(data)
+		}
+	}
+	if options.MaxBodySize > 0 && len(content) > options.MaxBodySize {
+		content = content[:options.MaxBodySize] + "..."
+	}
+	return content
+}
+
+func (options *LogOptions) redactValue(value interface{}) interface{} {
+	switch data := value.(type) {
+	case map[string]interface{}:
+		for key, item := range data {
+			if options.redactField(key) {
+				data[key] = redacted
+			} else {
+				data[key] = options.redactValue(item)
+			}
+		}
+	case []interface{}:
+		for index, item := range data {
+			data[index] = options.redactValue(item)
+		}
+	}
+	return value
+}
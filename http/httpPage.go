@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// PageResult 分页接口的标准响应中的data，和web.SuccessOfPage、web.SuccessOfCursor的格式一致
+type PageResult struct {
+	Items json.RawMessage `json:"items"`
+	// 页码分页
+	Total int64 `json:"total"`
+	Page  int   `json:"page"`
+	Size  int   `json:"size"`
+	// 游标分页，hasMore不为空时按照游标分页
+	NextCursor string `json:"nextCursor"`
+	HasMore    *bool  `json:"hasMore"`
+}
+
+// GetAllPages 依次获取分页接口的所有页，每一页的items交给handler处理，handler返回错误则停止；
+// 页码分页通过page、size参数请求，游标分页通过cursor、size参数请求
+func GetAllPages(ctx context.Context, url string, header http.Header, parameterMap map[string]string, size int, handler func(items json.RawMessage) error) error {
+	parameters := map[string]string{}
+	for key, value := range parameterMap {
+		parameters[key] = value
+	}
+	parameters["size"] = strconv.Itoa(size)
+
+	for page := 1; ; page++ {
+		if _, ok := parameters["cursor"]; !ok {
+			parameters["page"] = strconv.Itoa(page)
+		}
+
+		var pageResult PageResult
+		if _, err := New().Method(http.MethodGet).URL(url).Headers(header).Queries(parameters).IntoStandard(&pageResult).Do(ctx); err != nil {
+			return err
+		}
+		var items []json.RawMessage
+		if len(pageResult.Items) > 0 {
+			if err := json.Unmarshal(pageResult.Items, &items); err != nil {
+				return &DecodeError{URL: url, Body: pageResult.Items, Err: err}
+			}
+		}
+		if len(items) > 0 {
+			if err := handler(pageResult.Items); err != nil {
+				return err
+			}
+		}
+
+		if pageResult.HasMore != nil {
+			if !*pageResult.HasMore || pageResult.NextCursor == "" {
+				return nil
+			}
+			delete(parameters, "page")
+			parameters["cursor"] = pageResult.NextCursor
+			continue
+		}
+
+		// 服务端可能限制了每页的条数，按照返回的size判断；没有返回total时按照空页或者不满一页判断
+		pageSize := pageResult.Size
+		if pageSize <= 0 {
+			pageSize = size
+		}
+		if len(items) == 0 || len(items) < pageSize || (pageResult.Total > 0 && int64(page)*int64(pageSize) >= pageResult.Total) {
+			return nil
+		}
+	}
+}
+
+// GetAllPagesInto 获取分页接口的所有页，items追加到target中，target需要为集合的指针
+func GetAllPagesInto(ctx context.Context, url string, header http.Header, parameterMap map[string]string, size int, target interface{}) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.Elem().Kind() != reflect.Slice {
+		return errors.New("target of pages must be pointer of slice")
+	}
+	sliceValue := targetValue.Elem()
+
+	return GetAllPages(ctx, url, header, parameterMap, size, func(items json.RawMessage) error {
+		pageItems := reflect.New(sliceValue.Type())
+		if err := json.Unmarshal(items, pageItems.Interface()); err != nil {
+			return &DecodeError{URL: url, Body: items, Err: err}
+		}
+		sliceValue.Set(reflect.AppendSlice(sliceValue, pageItems.Elem()))
+		return nil
+	})
+}
@@ -0,0 +1,486 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ContentTypeJson = "application/json"
+	ContentTypeForm = "application/x-www-form-urlencoded"
+)
+
+// Request 请求构造器，如：http.New().Method("POST").URL(url).Header(k, v).Query(k, v).JSON(body).Do(ctx)
+type Request struct {
+	method     string
+	url        string
+	header     http.Header
+	query      url.Values
+	pathParams map[string]string
+
+	body        io.Reader
+	contentType string
+	form        url.Values
+	parts       []multipartPart
+
+	target      interface{}
+	standard    bool
+	retryPolicy *RetryPolicy
+	client      *HttpClient
+	err         error
+}
+
+type multipartPart struct {
+	fieldName string
+	fileName  string
+	value     string
+	reader    io.Reader
+	filePath  string
+}
+
+// New 创建请求构造器，默认为GET
+func New() *Request {
+	return &Request{method: http.MethodGet, header: http.Header{}}
+}
+
+func (request *Request) Method(method string) *Request {
+	request.method = strings.ToUpper(method)
+	return request
+}
+
+func (request *Request) URL(url string) *Request {
+	request.url = url
+	return request
+}
+
+// Header 设置请求头，覆盖同名的值
+func (request *Request) Header(key, value string) *Request {
+	request.header.Set(key, value)
+	return request
+}
+
+// Headers 添加一组请求头
+func (request *Request) Headers(header http.Header) *Request {
+	for key, values := range header {
+		for _, value := range values {
+			request.header.Add(key, value)
+		}
+	}
+	return request
+}
+
+// Query 添加参数，同名的参数会添加为多个值
+func (request *Request) Query(key string, values ...string) *Request {
+	if request.query == nil {
+		request.query = url.Values{}
+	}
+	request.query[key] = append(request.query[key], values...)
+	return request
+}
+
+// Queries 设置一组参数，覆盖同名的参数
+func (request *Request) Queries(parameterMap map[string]string) *Request {
+	for key, value := range parameterMap {
+		request.query.Del(key)
+		request.Query(key, value)
+	}
+	return request
+}
+
+// QueryValues 添加一组参数，同名的参数会添加为多个值
+func (request *Request) QueryValues(values url.Values) *Request {
+	for key, valueList := range values {
+		request.Query(key, valueList...)
+	}
+	return request
+}
+
+// PathParam 设置url路径中{name}占位符的值，比如：/users/{id}
+func (request *Request) PathParam(name, value string) *Request {
+	if request.pathParams == nil {
+		request.pathParams = map[string]string{}
+	}
+	request.pathParams[name] = value
+	return request
+}
+
+// JSON 请求体为对象序列化后的json
+func (request *Request) JSON(body interface{}) *Request {
+	bytesOfBody, err := json.Marshal(body)
+	if err != nil {
+		request.err = err
+		return request
+	}
+	return request.Body(bytes.NewReader(bytesOfBody), ContentTypeJson)
+}
+
+// Form 添加application/x-www-form-urlencoded的表单字段
+func (request *Request) Form(key, value string) *Request {
+	if request.form == nil {
+		request.form = url.Values{}
+	}
+	request.form.Add(key, value)
+	return request
+}
+
+// MultipartField 添加multipart/form-data的普通字段
+func (request *Request) MultipartField(fieldName, value string) *Request {
+	request.parts = append(request.parts, multipartPart{fieldName: fieldName, value: value})
+	return request
+}
+
+// MultipartFile 添加multipart/form-data的文件字段，内容从reader中流式读取
+func (request *Request) MultipartFile(fieldName, fileName string, reader io.Reader) *Request {
+	request.parts = append(request.parts, multipartPart{fieldName: fieldName, fileName: fileName, reader: reader})
+	return request
+}
+
+// MultipartFilePath 添加multipart/form-data的文件字段，发送时才打开文件并流式读取
+func (request *Request) MultipartFilePath(fieldName, filePath string) *Request {
+	request.parts = append(request.parts, multipartPart{fieldName: fieldName, fileName: filepath.Base(filePath), filePath: filePath})
+	return request
+}
+
+// Body 原始的请求体，从reader中流式读取，contentType为空则不设置Content-Type
+func (request *Request) Body(reader io.Reader, contentType string) *Request {
+	request.body = reader
+	request.contentType = contentType
+	return request
+}
+
+// Into 请求成功后将响应的json解析到target中，target需要为指针
+func (request *Request) Into(target interface{}) *Request {
+	request.target = target
+	return request
+}
+
+// IntoStandard 请求成功后按照标准响应(code、message、data)解析，业务失败返回BizError，data解析到target中
+func (request *Request) IntoStandard(target interface{}) *Request {
+	request.target = target
+	request.standard = true
+	return request
+}
+
+// Client 使用指定的客户端发送请求
+func (request *Request) Client(httpClient *HttpClient) *Request {
+	request.client = httpClient
+	return request
+}
+
+// Retry 设置本次请求的重试策略，覆盖全局的策略；nil则使用全局的策略
+func (request *Request) Retry(policy *RetryPolicy) *Request {
+	request.retryPolicy = policy
+	return request
+}
+
+// Do 发送请求；非2xx的响应同时返回响应和StatusError
+func (request *Request) Do(ctx context.Context) (*Response, error) {
+	httpRequest, err := request.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重试的多次请求属于同一个client span
+	httpRequest, span := startClientSpan(httpRequest)
+	response, err := request.execute(httpRequest)
+	statusCode := 0
+	if response != nil {
+		statusCode = response.StatusCode
+	}
+	endClientSpan(span, statusCode, err)
+	return response, err
+}
+
+// 按照重试策略发送请求并解析响应
+func (request *Request) execute(httpRequest *http.Request) (*Response, error) {
+	timeout := DefaultTimeout
+	if request.httpClient().timeout > 0 {
+		timeout = request.httpClient().timeout
+	}
+	ctx, cancel := callContext(httpRequest.Context(), timeout)
+	defer cancel()
+	httpRequest = httpRequest.WithContext(ctx)
+
+	policy := request.retryPolicy
+	if policy == nil {
+		policy = getRetryPolicy()
+	}
+	// 请求体无法重放的时候不重试
+	canRetry := policy != nil && policy.allowMethod(httpRequest.Method) && (httpRequest.Body == nil || httpRequest.GetBody != nil)
+	target := lbTargetOf(httpRequest.URL)
+
+	for attempt := 1; ; attempt++ {
+		response, err := request.send(httpRequest, target, attempt)
+		if !canRetry || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return request.finish(httpRequest, response, err)
+		}
+		switch err.(type) {
+		case *CircuitOpenError, *DiscoveryError, *LimitError:
+			return request.finish(httpRequest, response, err)
+		}
+		if response != nil && !policy.retryStatus(response.StatusCode) {
+			return request.finish(httpRequest, response, err)
+		}
+
+		backoff := policy.backoff(attempt, response)
+		logRetry(httpRequest, attempt+1, backoff)
+		if sleepCtx(ctx, backoff) != nil {
+			return request.finish(httpRequest, response, err)
+		}
+		if httpRequest.GetBody != nil {
+			body, err := httpRequest.GetBody()
+			if err != nil {
+				return request.finish(httpRequest, response, err)
+			}
+			httpRequest.Body = body
+		}
+	}
+}
+
+// 发送一次请求；网络错误时响应为nil
+func (request *Request) send(httpRequest *http.Request, target *lbTarget, attempt int) (response *Response, err error) {
+	startTime := time.Now()
+	defer func() {
+		logCall(httpRequest, response, err, time.Since(startTime), attempt)
+	}()
+
+	// lb://的请求每次发送都重新选择实例，重试时可以换到其他实例
+	if target != nil {
+		address, pickErr := target.pick(httpRequest.Context())
+		if pickErr != nil {
+			return nil, pickErr
+		}
+		httpRequest.URL.Scheme, httpRequest.URL.Host, httpRequest.Host = target.scheme, address, ""
+		defer func() {
+			// 熔断、限流等请求没有发出的错误不算实例的失败
+			_, transportErr := err.(*TransportError)
+			success := err == nil && response.StatusCode < http.StatusInternalServerError
+			failed := (err == nil && !success) || (transportErr && httpRequest.Context().Err() == nil)
+			target.done(address, success, failed)
+		}()
+	}
+
+	release, err := acquireLimit(httpRequest.Context(), request.httpClient().name, httpRequest.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	breaker := getCircuitBreaker(httpRequest.URL.Host)
+	if breaker != nil && !breaker.allow() {
+		return nil, &CircuitOpenError{Host: httpRequest.URL.Host}
+	}
+
+	response, err = request.exchange(httpRequest)
+	if breaker != nil {
+		if httpRequest.Context().Err() != nil {
+			breaker.release()
+		} else {
+			breaker.onResult(err == nil && response.StatusCode < http.StatusInternalServerError)
+		}
+	}
+	return response, err
+}
+
+func (request *Request) roundTrip(httpRequest *http.Request) (*http.Response, error) {
+	httpResponse, err := request.httpClient().do(httpRequest)
+	if err != nil {
+		return nil, &TransportError{Method: httpRequest.Method, URL: httpRequest.URL.String(), Err: err}
+	}
+	if httpResponse == nil {
+		return nil, &TransportError{Method: httpRequest.Method, URL: httpRequest.URL.String(), Err: errors.New("httpResponse is nil")}
+	}
+	return httpResponse, nil
+}
+
+func (request *Request) exchange(httpRequest *http.Request) (*Response, error) {
+	httpResponse, err := request.roundTrip(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			getLogger().Warnf("close response body err, url: %v, %v", httpRequest.URL.String(), err.Error())
+		}
+	}(httpResponse.Body)
+
+	body, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, &TransportError{Method: httpRequest.Method, URL: httpRequest.URL.String(), Err: err}
+	}
+	return &Response{StatusCode: httpResponse.StatusCode, Header: httpResponse.Header, Body: body, ContentLength: httpResponse.ContentLength}, nil
+}
+
+// 最终结果：2xx都为成功，然后解析响应
+func (request *Request) finish(httpRequest *http.Request, response *Response, err error) (*Response, error) {
+	if err != nil {
+		return response, err
+	}
+	if !response.IsSuccess() {
+		return response, &StatusError{Method: httpRequest.Method, URL: httpRequest.URL.String(), StatusCode: response.StatusCode, Header: response.Header, Body: response.Body}
+	}
+
+	if request.standard {
+		err = decodeStandard(response.Body, request.target)
+	} else if request.target != nil {
+		err = response.Decode(request.target)
+	}
+
+	if decodeError, ok := err.(*DecodeError); ok {
+		decodeError.URL = httpRequest.URL.String()
+	}
+	return response, err
+}
+
+func (request *Request) httpClient() *HttpClient {
+	if request.client == nil {
+		return defaultClient
+	}
+	return request.client
+}
+
+func (request *Request) build(ctx context.Context) (*http.Request, error) {
+	if request.err != nil {
+		return nil, request.err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	body, contentType, err := request.bodyReader()
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := request.httpClient()
+	requestUrl, err := UrlWithPathParam(httpClient.fullUrl(request.url), request.pathParams)
+	if err != nil {
+		return nil, err
+	}
+	if requestUrl, err = UrlWithQuery(requestUrl, request.query); err != nil {
+		return nil, err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, request.method, requestUrl, body)
+	if err != nil {
+		getLogger().Errorf("create request err, %v", err.Error())
+		return nil, err
+	}
+
+	for key, values := range httpClient.headers {
+		httpRequest.Header[key] = append([]string{}, values...)
+	}
+	for key, values := range request.header {
+		httpRequest.Header[key] = append([]string{}, values...)
+	}
+	if contentType != "" && httpRequest.Header.Get("Content-Type") == "" {
+		httpRequest.Header.Set("Content-Type", contentType)
+	}
+	return httpRequest, nil
+}
+
+func (request *Request) bodyReader() (io.Reader, string, error) {
+	if len(request.parts) > 0 {
+		return request.multipartReader()
+	}
+
+	if request.form != nil {
+		return strings.NewReader(request.form.Encode()), ContentTypeForm, nil
+	}
+	return request.body, request.contentType, nil
+}
+
+// 只有普通字段时在内存中生成请求体；有文件时边读文件边发送，不会把文件读到内存中
+func (request *Request) multipartReader() (io.Reader, string, error) {
+	streaming := false
+	for _, part := range request.parts {
+		if part.reader != nil || part.filePath != "" {
+			streaming = true
+		}
+	}
+
+	if !streaming {
+		var buffer bytes.Buffer
+		writer := multipart.NewWriter(&buffer)
+		if err := writeMultipart(writer, request.parts); err != nil {
+			return nil, "", err
+		}
+		return &buffer, writer.FormDataContentType(), nil
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
+	return &multipartPipe{reader: pipeReader, writer: pipeWriter, multipartWriter: writer, parts: request.parts}, writer.FormDataContentType(), nil
+}
+
+// 第一次读取时才启动写入请求体的协程，请求没有发出（熔断、限流等）时不会有协程阻塞在管道上；
+// 关闭时写入的协程随之结束
+type multipartPipe struct {
+	once            sync.Once
+	reader          *io.PipeReader
+	writer          *io.PipeWriter
+	multipartWriter *multipart.Writer
+	parts           []multipartPart
+}
+
+func (pipe *multipartPipe) Read(data []byte) (int, error) {
+	pipe.once.Do(func() {
+		go func() {
+			_ = pipe.writer.CloseWithError(writeMultipart(pipe.multipartWriter, pipe.parts))
+		}()
+	})
+	return pipe.reader.Read(data)
+}
+
+func (pipe *multipartPipe) Close() error {
+	return pipe.reader.Close()
+}
+
+func writeMultipart(writer *multipart.Writer, parts []multipartPart) error {
+	for _, part := range parts {
+		if part.reader == nil && part.filePath == "" {
+			if err := writer.WriteField(part.fieldName, part.value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fileWriter, err := writer.CreateFormFile(part.fieldName, part.fileName)
+		if err != nil {
+			return err
+		}
+		if err := copyPart(fileWriter, part); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func copyPart(writer io.Writer, part multipartPart) error {
+	if part.reader != nil {
+		_, err := io.Copy(writer, part.reader)
+		return err
+	}
+
+	file, err := os.Open(part.filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(writer, file)
+	return err
+}
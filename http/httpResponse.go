@@ -0,0 +1,38 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Response 响应，包括状态码、响应头和响应体
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// 内容长度，未知时为-1；HEAD请求时为响应头Content-Length的值
+	ContentLength int64
+}
+
+// IsSuccess 状态码是否为2xx
+func (response *Response) IsSuccess() bool {
+	return response.StatusCode >= http.StatusOK && response.StatusCode < http.StatusMultipleChoices
+}
+
+// Decode 将响应体的json解析到target中，target需要为指针
+func (response *Response) Decode(target interface{}) error {
+	if err := json.Unmarshal(response.Body, target); err != nil {
+		return &DecodeError{Body: response.Body, Err: err}
+	}
+	return nil
+}
+
+// LastModified 响应头Last-Modified的时间，没有或者格式不对则返回错误
+func (response *Response) LastModified() (time.Time, error) {
+	return http.ParseTime(response.Header.Get("Last-Modified"))
+}
+
+func (response *Response) String() string {
+	return string(response.Body)
+}
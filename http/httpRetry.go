@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy 重试策略：网络错误和指定的状态码重试，默认只重试幂等的方法
+type RetryPolicy struct {
+	// 最大尝试次数，包括第一次请求
+	MaxAttempts int
+	// 第一次重试前的回退时间，之后每次翻倍
+	InitialBackoff time.Duration
+	// 最大回退时间
+	MaxBackoff time.Duration
+	// 回退时间的随机抖动比例，取值0~1
+	Jitter float64
+	// 需要重试的http状态码
+	StatusCodes []int
+	// 非幂等的方法（POST、PATCH）是否也重试
+	RetryNonIdempotent bool
+}
+
+var retryPolicy *RetryPolicy
+var retryPolicyLock sync.RWMutex
+
+// DefaultRetryPolicy 默认的重试策略
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Jitter:         0.2,
+		StatusCodes:    []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// SetRetryPolicy 设置全局的重试策略，nil则不重试
+func SetRetryPolicy(policy *RetryPolicy) {
+	retryPolicyLock.Lock()
+	defer retryPolicyLock.Unlock()
+	retryPolicy = policy
+}
+
+func getRetryPolicy() *RetryPolicy {
+	retryPolicyLock.RLock()
+	defer retryPolicyLock.RUnlock()
+	return retryPolicy
+}
+
+func (policy *RetryPolicy) allowMethod(method string) bool {
+	if policy.RetryNonIdempotent {
+		return true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (policy *RetryPolicy) retryStatus(statusCode int) bool {
+	for _, code := range policy.StatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// 第attempt次请求失败后的等待时间，响应中有Retry-After则优先使用
+func (policy *RetryPolicy) backoff(attempt int, response *Response) time.Duration {
+	if response != nil {
+		if wait, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+			return wait
+		}
+	}
+
+	// 每次重试翻倍，MaxBackoff为0时不限制，翻倍溢出时停止
+	wait := policy.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		if (policy.MaxBackoff > 0 && wait >= policy.MaxBackoff) || wait > math.MaxInt64/2 {
+			break
+		}
+		wait *= 2
+	}
+	if policy.MaxBackoff > 0 && wait > policy.MaxBackoff {
+		wait = policy.MaxBackoff
+	}
+	if policy.Jitter > 0 {
+		wait += time.Duration((rand.Float64()*2 - 1) * policy.Jitter * float64(wait))
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// Retry-After支持秒数和http日期两种格式
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+func sleepCtx(ctx context.Context, wait time.Duration) error {
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
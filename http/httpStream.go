@@ -0,0 +1,266 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 非2xx的流式响应，错误中最多保留的响应体大小
+const maxErrorBodySize = 64 * 1024
+
+type streamKey struct{}
+
+// 是否为流式的请求，拦截器中不能读取流式请求的响应体
+func isStream(ctx context.Context) bool {
+	stream, _ := ctx.Value(streamKey{}).(bool)
+	return stream
+}
+
+// StreamResponse 流式的响应，响应体不读到内存中，Body需要调用方关闭
+type StreamResponse struct {
+	StatusCode    int
+	Header        http.Header
+	ContentLength int64
+	Body          io.ReadCloser
+}
+
+// Event server-sent events的事件
+type Event struct {
+	Id    string
+	Event string
+	Data  string
+	Retry int
+}
+
+// Stream 发送请求并返回流式的响应；不使用默认超时（ctx和WithTimeout的超时仍然生效），不重试；非2xx返回StatusError
+func (request *Request) Stream(ctx context.Context) (*StreamResponse, error) {
+	httpRequest, err := request.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 流式请求的client span在收到响应头时结束
+	httpRequest, span := startClientSpan(httpRequest)
+	ctx, cancel := callContext(httpRequest.Context(), 0)
+	ctx = context.WithValue(ctx, streamKey{}, true)
+	httpRequest = httpRequest.WithContext(ctx)
+
+	// lb://的实例和限流的许可在响应体关闭时才释放
+	done := func(success, failed bool) {}
+	if target := lbTargetOf(httpRequest.URL); target != nil {
+		address, err := target.pick(ctx)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		httpRequest.URL.Scheme, httpRequest.URL.Host, httpRequest.Host = target.scheme, address, ""
+		done = func(success, failed bool) {
+			target.done(address, success, failed)
+		}
+	}
+
+	release, err := acquireLimit(ctx, request.httpClient().name, httpRequest.URL.Host)
+	if err != nil {
+		done(false, false)
+		cancel()
+		return nil, err
+	}
+	lbDone := done
+	done = func(success, failed bool) {
+		release()
+		lbDone(success, failed)
+	}
+
+	// 和send一样经过熔断，结果按照响应头判断
+	breaker := getCircuitBreaker(httpRequest.URL.Host)
+	if breaker != nil && !breaker.allow() {
+		done(false, false)
+		cancel()
+		return nil, &CircuitOpenError{Host: httpRequest.URL.Host}
+	}
+	record := func(success bool) {
+		if breaker == nil {
+			return
+		}
+		if ctx.Err() != nil {
+			breaker.release()
+		} else {
+			breaker.onResult(success)
+		}
+	}
+
+	startTime := time.Now()
+	httpResponse, err := request.roundTrip(httpRequest)
+	if err != nil {
+		record(false)
+		logCall(httpRequest, nil, err, time.Since(startTime), 1)
+		endClientSpan(span, 0, err)
+		done(false, ctx.Err() == nil)
+		cancel()
+		return nil, err
+	}
+
+	if httpResponse.StatusCode < http.StatusOK || httpResponse.StatusCode >= http.StatusMultipleChoices {
+		defer cancel()
+		defer httpResponse.Body.Close()
+		success := httpResponse.StatusCode < http.StatusInternalServerError
+		record(success)
+		done(success, !success)
+		body, _ := ioutil.ReadAll(io.LimitReader(httpResponse.Body, maxErrorBodySize))
+		logCall(httpRequest, &Response{StatusCode: httpResponse.StatusCode, Header: httpResponse.Header, Body: body}, nil, time.Since(startTime), 1)
+		statusError := &StatusError{Method: httpRequest.Method, URL: httpRequest.URL.String(), StatusCode: httpResponse.StatusCode, Header: httpResponse.Header, Body: body}
+		endClientSpan(span, httpResponse.StatusCode, statusError)
+		return nil, statusError
+	}
+
+	record(true)
+	// 流式的响应体不记录，耗时为收到响应头的时间
+	logCall(httpRequest, &Response{StatusCode: httpResponse.StatusCode, Header: httpResponse.Header}, nil, time.Since(startTime), 1)
+	endClientSpan(span, httpResponse.StatusCode, nil)
+	return &StreamResponse{
+		StatusCode:    httpResponse.StatusCode,
+		Header:        httpResponse.Header,
+		ContentLength: httpResponse.ContentLength,
+		Body: &cancelReadCloser{ReadCloser: httpResponse.Body, cancel: func() {
+			cancel()
+			done(true, false)
+		}},
+	}, nil
+}
+
+// Download 下载响应体到文件，返回写入的字节数；progress不为nil时每次写入后回调已写入和总的字节数，总数未知时为-1；失败时删除文件
+func (request *Request) Download(ctx context.Context, filePath string, progress func(written, total int64)) (int64, error) {
+	stream, err := request.Stream(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer stream.Body.Close()
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	var writer io.Writer = file
+	if progress != nil {
+		writer = &progressWriter{writer: file, total: stream.ContentLength, progress: progress}
+	}
+	written, err := io.Copy(writer, stream.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(filePath)
+		return written, err
+	}
+	return written, nil
+}
+
+// ReadLines 按行读取响应体，比如NDJSON，空行跳过；handler返回错误则停止读取，读取完成后关闭响应体
+func (stream *StreamResponse) ReadLines(handler func(line string) error) error {
+	defer stream.Body.Close()
+
+	return readLines(stream.Body, func(line string) error {
+		if line == "" {
+			return nil
+		}
+		return handler(line)
+	})
+}
+
+// ReadEvents 按照server-sent events的格式读取事件；handler返回错误则停止读取，读取完成后关闭响应体
+func (stream *StreamResponse) ReadEvents(handler func(event *Event) error) error {
+	defer stream.Body.Close()
+
+	event := &Event{}
+	var dataList []string
+	err := readLines(stream.Body, func(line string) error {
+		// 空行表示一个事件结束
+		if line == "" {
+			if len(dataList) == 0 {
+				event = &Event{Id: event.Id}
+				return nil
+			}
+			event.Data = strings.Join(dataList, "\n")
+			dataList = nil
+			dispatched := event
+			event = &Event{Id: event.Id}
+			return handler(dispatched)
+		}
+		if strings.HasPrefix(line, ":") {
+			return nil
+		}
+
+		field, value := line, ""
+		if index := strings.Index(line, ":"); index >= 0 {
+			field, value = line[:index], strings.TrimPrefix(line[index+1:], " ")
+		}
+		switch field {
+		case "id":
+			event.Id = value
+		case "event":
+			event.Event = value
+		case "data":
+			dataList = append(dataList, value)
+		case "retry":
+			if retry, err := strconv.Atoi(value); err == nil {
+				event.Retry = retry
+			}
+		}
+		return nil
+	})
+	// 流结束时没有空行结尾的事件不分发
+	return err
+}
+
+func readLines(reader io.Reader, handler func(line string) error) error {
+	bufReader := bufio.NewReader(reader)
+	for {
+		line, err := bufReader.ReadString('\n')
+		if line != "" || err == nil {
+			if handlerErr := handler(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")); handlerErr != nil {
+				return handlerErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// 关闭响应体时取消ctx，多次关闭只取消一次
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+func (reader *cancelReadCloser) Close() error {
+	defer reader.once.Do(reader.cancel)
+	return reader.ReadCloser.Close()
+}
+
+type progressWriter struct {
+	writer   io.Writer
+	written  int64
+	total    int64
+	progress func(written, total int64)
+}
+
+func (writer *progressWriter) Write(data []byte) (int, error) {
+	n, err := writer.writer.Write(data)
+	writer.written += int64(n)
+	writer.progress(writer.written, writer.total)
+	return n, err
+}
@@ -0,0 +1,237 @@
+package httptest
+
+import (
+	"bytes"
+	"encoding/json"
+	goleHttp "github.com/isyscore/gole/http"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Mode 录制回放的模式
+type Mode int
+
+const (
+	// ModeReplay 只回放，没有匹配的记录则报错，不会发出真实的请求
+	ModeReplay Mode = iota
+	// ModeRecord 发出真实的请求并录制
+	ModeRecord
+	// ModeAuto 磁带文件存在则回放，否则录制
+	ModeAuto
+)
+
+// Cassette 磁带：录制的请求和响应
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction 一次请求和响应
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// RecordError 回放时没有匹配的记录
+type RecordError struct {
+	ErrMsg string
+}
+
+func (error *RecordError) Error() string {
+	return error.ErrMsg
+}
+
+// Recorder 录制回放器，作为拦截器使用：http.AddInterceptor(recorder.Interceptor())
+type Recorder struct {
+	// Matcher 回放时请求和记录是否匹配，默认比较方法、url和请求体
+	Matcher func(request *RecordedRequest, recorded *RecordedRequest) bool
+	// RedactHeaders 录制时替换为"***"的请求头，默认为Authorization和Cookie
+	RedactHeaders []string
+
+	lock         sync.Mutex
+	cassettePath string
+	mode         Mode
+	cassette     *Cassette
+	used         map[*Interaction]bool
+}
+
+// NewRecorder 创建录制回放器，回放模式下读取磁带文件
+func NewRecorder(cassettePath string, mode Mode) (*Recorder, error) {
+	if mode == ModeAuto {
+		mode = ModeRecord
+		if _, err := os.Stat(cassettePath); err == nil {
+			mode = ModeReplay
+		}
+	}
+
+	recorder := &Recorder{
+		Matcher:       defaultMatcher,
+		RedactHeaders: []string{"Authorization", "Cookie"},
+		cassettePath:  cassettePath,
+		mode:          mode,
+		cassette:      &Cassette{},
+		used:          map[*Interaction]bool{},
+	}
+	if mode == ModeReplay {
+		content, err := ioutil.ReadFile(cassettePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(content, recorder.cassette); err != nil {
+			return nil, err
+		}
+	}
+	return recorder, nil
+}
+
+// Mode 实际的模式，ModeAuto会转换为ModeReplay或者ModeRecord
+func (recorder *Recorder) Mode() Mode {
+	return recorder.mode
+}
+
+// Interactions 录制或者读取的交互
+func (recorder *Recorder) Interactions() []*Interaction {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	return append([]*Interaction{}, recorder.cassette.Interactions...)
+}
+
+// Interceptor 拦截器：录制模式下记录真实的请求和响应，回放模式下直接返回记录的响应
+func (recorder *Recorder) Interceptor() goleHttp.Interceptor {
+	return func(req *http.Request, next goleHttp.Handler) (*http.Response, error) {
+		body, err := readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		recordedRequest := RecordedRequest{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone(), Body: string(body)}
+
+		if recorder.mode == ModeReplay {
+			return recorder.replay(req, &recordedRequest)
+		}
+
+		rsp, err := next(req)
+		if err != nil {
+			return rsp, err
+		}
+		rspBody, err := ioutil.ReadAll(rsp.Body)
+		_ = rsp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		rsp.Body = ioutil.NopCloser(bytes.NewReader(rspBody))
+
+		for _, headerName := range recorder.RedactHeaders {
+			if recordedRequest.Header.Get(headerName) != "" {
+				recordedRequest.Header.Set(headerName, "***")
+			}
+		}
+		recorder.lock.Lock()
+		recorder.cassette.Interactions = append(recorder.cassette.Interactions, &Interaction{
+			Request:  recordedRequest,
+			Response: RecordedResponse{StatusCode: rsp.StatusCode, Header: rsp.Header.Clone(), Body: string(rspBody)},
+		})
+		recorder.lock.Unlock()
+		return rsp, nil
+	}
+}
+
+// Save 录制模式下将磁带写入文件
+func (recorder *Recorder) Save() error {
+	if recorder.mode != ModeRecord {
+		return nil
+	}
+
+	recorder.lock.Lock()
+	content, err := json.MarshalIndent(recorder.cassette, "", "  ")
+	recorder.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(recorder.cassettePath), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(recorder.cassettePath, content, 0644)
+}
+
+// 按照录制的顺序使用第一个没有用过的匹配记录，都用过则使用第一个匹配的记录
+func (recorder *Recorder) replay(req *http.Request, request *RecordedRequest) (*http.Response, error) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+
+	var matched *Interaction
+	for _, interaction := range recorder.cassette.Interactions {
+		if !recorder.Matcher(request, &interaction.Request) {
+			continue
+		}
+		if !recorder.used[interaction] {
+			matched = interaction
+			break
+		}
+		if matched == nil {
+			matched = interaction
+		}
+	}
+	if matched == nil {
+		return nil, &RecordError{ErrMsg: "no recorded interaction matched: " + request.Method + " " + request.URL}
+	}
+	recorder.used[matched] = true
+
+	return &http.Response{
+		Status:        http.StatusText(matched.Response.StatusCode),
+		StatusCode:    matched.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        matched.Response.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(matched.Response.Body))),
+		ContentLength: int64(len(matched.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func defaultMatcher(request *RecordedRequest, recorded *RecordedRequest) bool {
+	return request.Method == recorded.Method && request.URL == recorded.URL && request.Body == recorded.Body
+}
+
+// IgnoreHostMatcher 比较方法、路径、参数和请求体，忽略协议和host，适合每次端口都不同的本地服务
+func IgnoreHostMatcher(request *RecordedRequest, recorded *RecordedRequest) bool {
+	requestUrl, err := url.Parse(request.URL)
+	if err != nil {
+		return false
+	}
+	recordedUrl, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	return request.Method == recorded.Method && requestUrl.RequestURI() == recordedUrl.RequestURI() && request.Body == recorded.Body
+}
+
+// 读取请求体并还原，后续的发送仍然可以读取
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,297 @@
+package httptest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Server 可编程的桩服务，按照路由返回预设的响应，并记录收到的请求用于断言
+type Server struct {
+	*httptest.Server
+
+	lock      sync.Mutex
+	routes    []*Route
+	unmatched []*Request
+}
+
+// Route 路由：method、路径以及可选的参数和请求头匹配条件
+type Route struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+
+	status      int
+	replyHeader http.Header
+	body        []byte
+	handler     http.HandlerFunc
+
+	requests []*Request
+}
+
+// Request 桩服务收到的请求
+type Request struct {
+	Method     string
+	Path       string
+	Query      url.Values
+	Header     http.Header
+	Body       []byte
+	PathParams map[string]string
+}
+
+// JSON 将请求体的json解析到target中
+func (request *Request) JSON(target interface{}) error {
+	return json.Unmarshal(request.Body, target)
+}
+
+// NewServer 创建并启动桩服务，使用完需要Close
+func NewServer() *Server {
+	server := &Server{}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	return server
+}
+
+// On 添加路由，method为空或者*则匹配所有方法；path中{name}匹配一段路径，结尾的*匹配剩余的所有路径；先添加的路由优先匹配
+func (server *Server) On(method, path string) *Route {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	route := &Route{method: strings.ToUpper(method), path: path, status: http.StatusOK, replyHeader: http.Header{}}
+	server.routes = append(server.routes, route)
+	return route
+}
+
+// Requests 所有收到的请求，包括没有匹配到路由的请求
+func (server *Server) Requests() []*Request {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	var requests []*Request
+	for _, route := range server.routes {
+		requests = append(requests, route.requests...)
+	}
+	return append(requests, server.unmatched...)
+}
+
+// Unmatched 没有匹配到路由的请求
+func (server *Server) Unmatched() []*Request {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	return append([]*Request{}, server.unmatched...)
+}
+
+// Reset 清空路由和记录的请求
+func (server *Server) Reset() {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	server.routes = nil
+	server.unmatched = nil
+}
+
+// AssertCalled 断言路由被调用了times次
+func (server *Server) AssertCalled(t testing.TB, method, path string, times int) {
+	t.Helper()
+	count := 0
+	for _, request := range server.Requests() {
+		if (method == "" || strings.EqualFold(method, request.Method)) && request.Path == path {
+			count++
+		}
+	}
+	if count != times {
+		t.Errorf("expect %v %v called %v times, actual %v times", method, path, times, count)
+	}
+}
+
+// AssertAllCalled 断言所有路由都至少调用过一次，并且没有未匹配的请求
+func (server *Server) AssertAllCalled(t testing.TB) {
+	t.Helper()
+	server.lock.Lock()
+	defer server.lock.Unlock()
+
+	for _, route := range server.routes {
+		if len(route.requests) == 0 {
+			t.Errorf("route %v %v is not called", route.method, route.path)
+		}
+	}
+	for _, request := range server.unmatched {
+		t.Errorf("request %v %v is not matched", request.Method, request.Path)
+	}
+}
+
+func (server *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	request := &Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header, Body: body}
+
+	server.lock.Lock()
+	var matched *Route
+	for _, route := range server.routes {
+		if pathParams, ok := route.match(r); ok {
+			request.PathParams = pathParams
+			route.requests = append(route.requests, request)
+			matched = route
+			break
+		}
+	}
+	if matched == nil {
+		server.unmatched = append(server.unmatched, request)
+	}
+	server.lock.Unlock()
+
+	if matched == nil {
+		http.Error(w, "no route matched: "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+		return
+	}
+	matched.reply(w, r, body)
+}
+
+// WithQuery 请求中需要有该参数
+func (route *Route) WithQuery(key, value string) *Route {
+	if route.query == nil {
+		route.query = url.Values{}
+	}
+	route.query.Add(key, value)
+	return route
+}
+
+// WithHeader 请求中需要有该请求头
+func (route *Route) WithHeader(key, value string) *Route {
+	if route.header == nil {
+		route.header = http.Header{}
+	}
+	route.header.Add(key, value)
+	return route
+}
+
+// Reply 返回的状态码和响应体，body为string和[]byte时原样返回，其他的转换为json
+func (route *Route) Reply(status int, body interface{}) *Route {
+	route.status = status
+	switch value := body.(type) {
+	case nil:
+		route.body = nil
+	case string:
+		route.body = []byte(value)
+	case []byte:
+		route.body = value
+	default:
+		route.body, _ = json.Marshal(value)
+		if route.replyHeader.Get("Content-Type") == "" {
+			route.replyHeader.Set("Content-Type", "application/json; charset=utf-8")
+		}
+	}
+	return route
+}
+
+// ReplyHeader 返回的响应头
+func (route *Route) ReplyHeader(key, value string) *Route {
+	route.replyHeader.Set(key, value)
+	return route
+}
+
+// ReplySuccess 返回成功的标准响应
+func (route *Route) ReplySuccess(data interface{}) *Route {
+	return route.Reply(http.StatusOK, map[string]interface{}{"code": "success", "message": "成功", "data": data})
+}
+
+// ReplyFailed 返回失败的标准响应
+func (route *Route) ReplyFailed(code interface{}, message string) *Route {
+	return route.Reply(http.StatusOK, map[string]interface{}{"code": code, "message": message, "data": nil})
+}
+
+// ReplyFunc 自定义处理，请求体可以再次读取
+func (route *Route) ReplyFunc(handler http.HandlerFunc) *Route {
+	route.handler = handler
+	return route
+}
+
+// Calls 路由被调用的次数
+func (route *Route) Calls() int {
+	return len(route.requests)
+}
+
+// Requests 路由收到的请求
+func (route *Route) Requests() []*Request {
+	return append([]*Request{}, route.requests...)
+}
+
+// LastRequest 路由收到的最后一个请求，没有则为nil
+func (route *Route) LastRequest() *Request {
+	if len(route.requests) == 0 {
+		return nil
+	}
+	return route.requests[len(route.requests)-1]
+}
+
+func (route *Route) match(r *http.Request) (map[string]string, bool) {
+	if route.method != "" && route.method != "*" && route.method != r.Method {
+		return nil, false
+	}
+	for key, values := range route.query {
+		for _, value := range values {
+			if !contains(r.URL.Query()[key], value) {
+				return nil, false
+			}
+		}
+	}
+	for key, values := range route.header {
+		for _, value := range values {
+			if !contains(r.Header.Values(key), value) {
+				return nil, false
+			}
+		}
+	}
+	return matchPath(route.path, r.URL.Path)
+}
+
+func (route *Route) reply(w http.ResponseWriter, r *http.Request, body []byte) {
+	if route.handler != nil {
+		r.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+		route.handler(w, r)
+		return
+	}
+
+	for key, values := range route.replyHeader {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(route.status)
+	_, _ = w.Write(route.body)
+}
+
+func matchPath(pattern, path string) (map[string]string, bool) {
+	pathParams := map[string]string{}
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	for index, segment := range patternSegments {
+		if segment == "*" && index == len(patternSegments)-1 {
+			return pathParams, true
+		}
+		if index >= len(pathSegments) {
+			return nil, false
+		}
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			pathParams[segment[1:len(segment)-1]] = pathSegments[index]
+			continue
+		}
+		if segment != pathSegments[index] {
+			return nil, false
+		}
+	}
+	if len(pathSegments) != len(patternSegments) {
+		return nil, false
+	}
+	return pathParams, true
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
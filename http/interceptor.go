@@ -0,0 +1,191 @@
+package http
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Handler 发送请求
+type Handler func(req *http.Request) (*http.Response, error)
+
+// Interceptor 拦截器，调用next继续发送请求，也可以不调用直接返回；重试时每次请求都会经过拦截器
+type Interceptor func(req *http.Request, next Handler) (*http.Response, error)
+
+var globalInterceptors []Interceptor
+var interceptorLock sync.RWMutex
+
+// AddInterceptor 添加全局拦截器，对所有的客户端生效
+func AddInterceptor(interceptors ...Interceptor) {
+	interceptorLock.Lock()
+	defer interceptorLock.Unlock()
+	globalInterceptors = append(globalInterceptors, interceptors...)
+}
+
+// ClearInterceptors 清空全局拦截器
+func ClearInterceptors() {
+	interceptorLock.Lock()
+	defer interceptorLock.Unlock()
+	globalInterceptors = nil
+}
+
+func getGlobalInterceptors() []Interceptor {
+	interceptorLock.RLock()
+	defer interceptorLock.RUnlock()
+	return append([]Interceptor{}, globalInterceptors...)
+}
+
+// 拦截器按照添加的顺序执行，最后一个拦截器的next为真正的发送
+func chain(interceptors []Interceptor, handler Handler) Handler {
+	for index := len(interceptors) - 1; index >= 0; index-- {
+		interceptor, next := interceptors[index], handler
+		handler = func(req *http.Request) (*http.Response, error) {
+			return interceptor(req, next)
+		}
+	}
+	return handler
+}
+
+// ------------------ auth ------------------
+
+// AuthInterceptor 添加鉴权头，token由tokenFn按照请求获取，为空则不添加
+func AuthInterceptor(headerName string, tokenFn func(ctx context.Context) (string, error)) Interceptor {
+	return func(req *http.Request, next Handler) (*http.Response, error) {
+		token, err := tokenFn(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			req.Header.Set(headerName, token)
+		}
+		return next(req)
+	}
+}
+
+// ------------------ log ------------------
+
+// LogInterceptor 通过日志记录请求和响应，logger为nil则不记录
+func LogInterceptor(logger *logrus.Logger) Interceptor {
+	return func(req *http.Request, next Handler) (*http.Response, error) {
+		if logger == nil {
+			return next(req)
+		}
+
+		startTime := time.Now()
+		rsp, err := next(req)
+		if err != nil {
+			logger.Errorf("%v %v failed, cost %v, err: %v", req.Method, req.URL.String(), time.Since(startTime), err)
+			return rsp, err
+		}
+		logger.Infof("%v %v %v, cost %v", req.Method, req.URL.String(), rsp.StatusCode, time.Since(startTime))
+		return rsp, err
+	}
+}
+
+// ------------------ header propagation ------------------
+
+type inboundRequestKey struct{}
+
+// WithGinContext 将gin的请求ctx作为出站请求的ctx，入站请求取消时出站请求也会取消，并且可以透传入站请求的头
+func WithGinContext(c *gin.Context) context.Context {
+	return context.WithValue(c.Request.Context(), inboundRequestKey{}, c.Request)
+}
+
+// PropagateHeaderInterceptor 将入站请求中的头透传到出站请求，出站请求中已经有的头不覆盖；
+// ctx需要为WithGinContext返回的ctx或者gin.Context本身
+func PropagateHeaderInterceptor(headerNames ...string) Interceptor {
+	return func(req *http.Request, next Handler) (*http.Response, error) {
+		if inbound := inboundRequest(req.Context()); inbound != nil {
+			for _, headerName := range headerNames {
+				if value := inbound.Header.Get(headerName); value != "" && req.Header.Get(headerName) == "" {
+					req.Header.Set(headerName, value)
+				}
+			}
+		}
+		return next(req)
+	}
+}
+
+func inboundRequest(ctx context.Context) *http.Request {
+	if inbound, ok := ctx.Value(inboundRequestKey{}).(*http.Request); ok {
+		return inbound
+	}
+	// gin.Context的Value(0)返回的是入站请求
+	if inbound, ok := ctx.Value(0).(*http.Request); ok {
+		return inbound
+	}
+	return nil
+}
+
+// ------------------ metrics ------------------
+
+// Metric 一次请求的指标
+type Metric struct {
+	Method     string
+	Host       string
+	Path       string
+	StatusCode int
+	Err        error
+	Latency    time.Duration
+}
+
+// MetricsInterceptor 每次请求结束后将指标交给recorder
+func MetricsInterceptor(recorder func(metric Metric)) Interceptor {
+	return func(req *http.Request, next Handler) (*http.Response, error) {
+		startTime := time.Now()
+		rsp, err := next(req)
+
+		metric := Metric{Method: req.Method, Host: req.URL.Host, Path: req.URL.Path, Err: err, Latency: time.Since(startTime)}
+		if rsp != nil {
+			metric.StatusCode = rsp.StatusCode
+		}
+		recorder(metric)
+		return rsp, err
+	}
+}
+
+// HostCounter 按照host统计的请求数、失败数和耗时
+type HostCounter struct {
+	Requests     int64
+	Failures     int64
+	TotalLatency time.Duration
+}
+
+// MetricsCounter 简单的指标统计，Record可以作为MetricsInterceptor的recorder
+type MetricsCounter struct {
+	lock     sync.Mutex
+	counters map[string]HostCounter
+}
+
+func NewMetricsCounter() *MetricsCounter {
+	return &MetricsCounter{counters: map[string]HostCounter{}}
+}
+
+// Record 记录指标，网络错误和5xx都算失败
+func (metricsCounter *MetricsCounter) Record(metric Metric) {
+	metricsCounter.lock.Lock()
+	defer metricsCounter.lock.Unlock()
+
+	counter := metricsCounter.counters[metric.Host]
+	counter.Requests++
+	if metric.Err != nil || metric.StatusCode >= http.StatusInternalServerError {
+		counter.Failures++
+	}
+	counter.TotalLatency += metric.Latency
+	metricsCounter.counters[metric.Host] = counter
+}
+
+// Counters 当前统计的快照
+func (metricsCounter *MetricsCounter) Counters() map[string]HostCounter {
+	metricsCounter.lock.Lock()
+	defer metricsCounter.lock.Unlock()
+
+	counters := map[string]HostCounter{}
+	for host, counter := range metricsCounter.counters {
+		counters[host] = counter
+	}
+	return counters
+}
@@ -0,0 +1,240 @@
+package http
+
+import (
+	"context"
+	"math/rand"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Instance 服务的实例以及实例上正在进行的请求数
+type Instance struct {
+	Address string
+	Pending int64
+}
+
+// Balancer 负载均衡，从可用的实例中选择一个，instances不为空
+type Balancer interface {
+	Pick(serviceName string, instances []*Instance) *Instance
+}
+
+// OutlierPolicy 异常实例摘除策略
+type OutlierPolicy struct {
+	// 实例连续失败多少次后摘除
+	ConsecutiveFailures int
+	// 摘除的时长，多次摘除时按照次数翻倍
+	EjectionTime time.Duration
+	// 最多摘除的实例比例，取值0~100
+	MaxEjectionPercent int
+}
+
+var balancer Balancer = &RoundRobinBalancer{}
+var outlierPolicy = DefaultOutlierPolicy()
+var balancerLock sync.RWMutex
+var serviceStates sync.Map
+
+// 多次摘除时摘除时长最多翻倍的次数
+const maxEjectionShift = 5
+
+// SetBalancer 设置负载均衡策略，默认为轮询
+func SetBalancer(serviceBalancer Balancer) {
+	balancerLock.Lock()
+	defer balancerLock.Unlock()
+	balancer = serviceBalancer
+}
+
+func getBalancer() Balancer {
+	balancerLock.RLock()
+	defer balancerLock.RUnlock()
+	return balancer
+}
+
+// BalancerOf 按照名字获取负载均衡策略：roundRobin/random/leastPending，其他的返回轮询
+func BalancerOf(name string) Balancer {
+	switch name {
+	case "random":
+		return &RandomBalancer{}
+	case "leastPending":
+		return &LeastPendingBalancer{}
+	}
+	return &RoundRobinBalancer{}
+}
+
+// DefaultOutlierPolicy 默认的异常实例摘除策略
+func DefaultOutlierPolicy() *OutlierPolicy {
+	return &OutlierPolicy{ConsecutiveFailures: 5, EjectionTime: 30 * time.Second, MaxEjectionPercent: 50}
+}
+
+// SetOutlierPolicy 设置异常实例摘除策略，nil则不摘除；已有的摘除状态会被清空
+func SetOutlierPolicy(policy *OutlierPolicy) {
+	balancerLock.Lock()
+	defer balancerLock.Unlock()
+	outlierPolicy = policy
+	serviceStates.Range(func(key, value interface{}) bool {
+		serviceStates.Delete(key)
+		return true
+	})
+}
+
+// ------------------ balancer ------------------
+
+// RoundRobinBalancer 轮询
+type RoundRobinBalancer struct {
+	counters sync.Map
+}
+
+func (roundRobin *RoundRobinBalancer) Pick(serviceName string, instances []*Instance) *Instance {
+	counter, _ := roundRobin.counters.LoadOrStore(serviceName, new(uint64))
+	index := atomic.AddUint64(counter.(*uint64), 1) - 1
+	return instances[index%uint64(len(instances))]
+}
+
+// RandomBalancer 随机
+type RandomBalancer struct{}
+
+func (random *RandomBalancer) Pick(serviceName string, instances []*Instance) *Instance {
+	return instances[rand.Intn(len(instances))]
+}
+
+// LeastPendingBalancer 正在进行的请求数最少的实例，相同的随机选择
+type LeastPendingBalancer struct{}
+
+func (leastPending *LeastPendingBalancer) Pick(serviceName string, instances []*Instance) *Instance {
+	var candidates []*Instance
+	for _, instance := range instances {
+		if len(candidates) == 0 || instance.Pending < candidates[0].Pending {
+			candidates = []*Instance{instance}
+		} else if instance.Pending == candidates[0].Pending {
+			candidates = append(candidates, instance)
+		}
+	}
+	return candidates[rand.Intn(len(candidates))]
+}
+
+// ------------------ instance state ------------------
+
+type instanceState struct {
+	pending      int64
+	failures     int
+	ejections    int
+	ejectedUntil time.Time
+}
+
+type serviceState struct {
+	lock      sync.Mutex
+	instances map[string]*instanceState
+}
+
+// lb://的请求目标
+type lbTarget struct {
+	serviceName string
+	scheme      string
+}
+
+func lbTargetOf(requestUrl *url.URL) *lbTarget {
+	switch requestUrl.Scheme {
+	case "lb":
+		return &lbTarget{serviceName: requestUrl.Host, scheme: "http"}
+	case "lbs":
+		return &lbTarget{serviceName: requestUrl.Host, scheme: "https"}
+	}
+	return nil
+}
+
+func getServiceState(serviceName string) *serviceState {
+	state, _ := serviceStates.LoadOrStore(serviceName, &serviceState{instances: map[string]*instanceState{}})
+	return state.(*serviceState)
+}
+
+// 选择实例：摘除的实例不参与选择，全部都被摘除时使用全部实例
+func (target *lbTarget) pick(ctx context.Context) (string, error) {
+	addresses, err := getResolver().Resolve(ctx, target.serviceName)
+	if err != nil {
+		return "", &DiscoveryError{ServiceName: target.serviceName, Err: err}
+	}
+	if len(addresses) == 0 {
+		return "", &DiscoveryError{ServiceName: target.serviceName}
+	}
+
+	state := getServiceState(target.serviceName)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	now := time.Now()
+	var available, all []*Instance
+	resolved := map[string]bool{}
+	for _, address := range addresses {
+		resolved[address] = true
+		instance := state.instances[address]
+		if instance == nil {
+			instance = &instanceState{}
+			state.instances[address] = instance
+		}
+		all = append(all, &Instance{Address: address, Pending: instance.pending})
+		if !now.Before(instance.ejectedUntil) {
+			available = append(available, all[len(all)-1])
+		}
+	}
+	if len(available) == 0 {
+		available = all
+	}
+	// 服务发现不再返回的实例不再保留状态，还有请求进行中的等请求结束后再删除
+	for address, instance := range state.instances {
+		if !resolved[address] && instance.pending <= 0 {
+			delete(state.instances, address)
+		}
+	}
+
+	address := getBalancer().Pick(target.serviceName, available).Address
+	state.instances[address].pending++
+	return address, nil
+}
+
+// 请求结束，failed为false时表示成功或者没有结果（比如调用方取消）
+func (target *lbTarget) done(address string, success, failed bool) {
+	state := getServiceState(target.serviceName)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	instance := state.instances[address]
+	if instance == nil {
+		return
+	}
+	instance.pending--
+	if success {
+		instance.failures = 0
+		instance.ejections = 0
+		return
+	}
+
+	balancerLock.RLock()
+	policy := outlierPolicy
+	balancerLock.RUnlock()
+	if !failed || policy == nil || policy.ConsecutiveFailures <= 0 {
+		return
+	}
+	instance.failures++
+	if instance.failures < policy.ConsecutiveFailures {
+		return
+	}
+
+	now := time.Now()
+	ejected := 0
+	for _, other := range state.instances {
+		if now.Before(other.ejectedUntil) {
+			ejected++
+		}
+	}
+	if (ejected+1)*100 > policy.MaxEjectionPercent*len(state.instances) {
+		return
+	}
+	shift := instance.ejections
+	if shift > maxEjectionShift {
+		shift = maxEjectionShift
+	}
+	instance.ejectedUntil = now.Add(policy.EjectionTime * time.Duration(1<<uint(shift)))
+	instance.ejections++
+	instance.failures = 0
+}
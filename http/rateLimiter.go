@@ -0,0 +1,195 @@
+package http
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// LimitPolicy 限流策略，按照host或者命名的客户端区分
+type LimitPolicy struct {
+	// 每秒允许的请求数（令牌桶），<=0不限制
+	Rate float64
+	// 令牌桶的容量，即允许突发的请求数，<=0时为Rate向上取整
+	Burst int
+	// 同时进行的最大请求数，<=0不限制
+	MaxInFlight int
+	// 超过限制时直接返回LimitError，默认等待直到允许或者ctx结束
+	FailFast bool
+}
+
+// LimitError 超过限流，请求没有发出
+type LimitError struct {
+	// host或者client:客户端名
+	Key string
+	// rate或者concurrency
+	Reason string
+}
+
+func (error *LimitError) Error() string {
+	return "request limited by " + error.Reason + ", key: " + error.Key
+}
+
+type rateLimiter struct {
+	lock     sync.Mutex
+	key      string
+	policy   LimitPolicy
+	tokens   float64
+	last     time.Time
+	inFlight int
+	// 释放或者策略变更时关闭，唤醒等待的请求
+	changed chan struct{}
+}
+
+var rateLimiters sync.Map
+
+// SetHostLimit 设置host（包括端口）的限流，nil则取消；已有的限流状态（令牌、并发数）保留
+func SetHostLimit(host string, policy *LimitPolicy) {
+	setLimit(host, policy)
+}
+
+// SetClientLimit 设置命名客户端的限流，nil则取消
+func SetClientLimit(name string, policy *LimitPolicy) {
+	setLimit("client:"+name, policy)
+}
+
+func setLimit(key string, policy *LimitPolicy) {
+	if policy == nil {
+		if limiter, ok := rateLimiters.Load(key); ok {
+			rateLimiters.Delete(key)
+			limiter.(*rateLimiter).update(LimitPolicy{})
+		}
+		return
+	}
+	limiter, loaded := rateLimiters.LoadOrStore(key, newRateLimiter(key, *policy))
+	if loaded {
+		limiter.(*rateLimiter).update(*policy)
+	}
+}
+
+func getRateLimiter(key string) *rateLimiter {
+	limiter, ok := rateLimiters.Load(key)
+	if !ok {
+		return nil
+	}
+	return limiter.(*rateLimiter)
+}
+
+func newRateLimiter(key string, policy LimitPolicy) *rateLimiter {
+	limiter := &rateLimiter{key: key, last: time.Now(), changed: make(chan struct{})}
+	limiter.policy = policy
+	limiter.tokens = float64(limiter.burst())
+	return limiter
+}
+
+func (limiter *rateLimiter) burst() int {
+	if limiter.policy.Burst > 0 {
+		return limiter.policy.Burst
+	}
+	return int(math.Max(1, math.Ceil(limiter.policy.Rate)))
+}
+
+func (limiter *rateLimiter) refill(now time.Time) {
+	if limiter.policy.Rate > 0 {
+		limiter.tokens = math.Min(float64(limiter.burst()), limiter.tokens+now.Sub(limiter.last).Seconds()*limiter.policy.Rate)
+	}
+	limiter.last = now
+}
+
+func (limiter *rateLimiter) update(policy LimitPolicy) {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+
+	limiter.refill(time.Now())
+	limiter.policy = policy
+	limiter.tokens = math.Min(limiter.tokens, float64(limiter.burst()))
+	limiter.notify()
+}
+
+func (limiter *rateLimiter) notify() {
+	close(limiter.changed)
+	limiter.changed = make(chan struct{})
+}
+
+// 获取许可，成功后需要release；FailFast时超过限制返回LimitError，否则等待到允许或者ctx结束
+func (limiter *rateLimiter) acquire(ctx context.Context) error {
+	for {
+		limiter.lock.Lock()
+		limiter.refill(time.Now())
+		policy := limiter.policy
+		rateAllowed := policy.Rate <= 0 || limiter.tokens >= 1
+		concurrencyAllowed := policy.MaxInFlight <= 0 || limiter.inFlight < policy.MaxInFlight
+		if rateAllowed && concurrencyAllowed {
+			if policy.Rate > 0 {
+				limiter.tokens--
+			}
+			limiter.inFlight++
+			limiter.lock.Unlock()
+			return nil
+		}
+		if policy.FailFast {
+			limiter.lock.Unlock()
+			if !rateAllowed {
+				return &LimitError{Key: limiter.key, Reason: "rate"}
+			}
+			return &LimitError{Key: limiter.key, Reason: "concurrency"}
+		}
+
+		changed := limiter.changed
+		// 只有并发超过限制时等待释放，令牌不足时还需要等待令牌恢复
+		var timer *time.Timer
+		var wait <-chan time.Time
+		if !rateAllowed {
+			timer = time.NewTimer(time.Duration((1 - limiter.tokens) / policy.Rate * float64(time.Second)))
+			wait = timer.C
+		}
+		limiter.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-wait:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+func (limiter *rateLimiter) release() {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	limiter.inFlight--
+	limiter.notify()
+}
+
+// 依次获取客户端和host的许可，返回的函数用于释放
+func acquireLimit(ctx context.Context, clientName, host string) (func(), error) {
+	var acquired []*rateLimiter
+	release := func() {
+		for _, limiter := range acquired {
+			limiter.release()
+		}
+	}
+
+	keys := []string{host}
+	if clientName != "" {
+		keys = []string{"client:" + clientName, host}
+	}
+	for _, key := range keys {
+		limiter := getRateLimiter(key)
+		if limiter == nil {
+			continue
+		}
+		if err := limiter.acquire(ctx); err != nil {
+			release()
+			return nil, err
+		}
+		acquired = append(acquired, limiter)
+	}
+	return release, nil
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// 标准响应中表示业务成功的code，数字和字符串都按照文本比较
+var successCodes = []string{"0", "200", "success"}
+var successCodeLock sync.RWMutex
+
+// SetSuccessCodes 设置标准响应中表示业务成功的code
+func SetSuccessCodes(codes ...interface{}) {
+	var codeList []string
+	for _, code := range codes {
+		codeList = append(codeList, fmt.Sprintf("%v", code))
+	}
+	successCodeLock.Lock()
+	defer successCodeLock.Unlock()
+	successCodes = codeList
+}
+
+// SuccessCodes 标准响应中表示业务成功的code，返回副本
+func SuccessCodes() []string {
+	successCodeLock.RLock()
+	defer successCodeLock.RUnlock()
+	return append([]string{}, successCodes...)
+}
+
+// BizError 标准响应中的业务失败
+type BizError struct {
+	Code    string
+	Message string
+	Data    json.RawMessage
+}
+
+func (error *BizError) Error() string {
+	return "remote err, bizCode=" + error.Code + ", message=" + error.Message
+}
+
+type standardEnvelope struct {
+	Code    interface{}     `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// 解析标准响应，返回data的原始json
+func parseStandard(responseResult []byte, errs error) ([]byte, error) {
+	if errs != nil {
+		return nil, errs
+	}
+
+	var envelope standardEnvelope
+	decoder := json.NewDecoder(bytes.NewReader(responseResult))
+	decoder.UseNumber()
+	if err := decoder.Decode(&envelope); err != nil {
+		return nil, &DecodeError{Body: responseResult, Err: err}
+	}
+
+	if envelope.Code == nil {
+		return nil, &DecodeError{Body: responseResult, Err: errors.New("code is nil")}
+	}
+
+	// 判断业务的失败信息
+	code := fmt.Sprintf("%v", envelope.Code)
+	if !isSuccessCode(code) {
+		return nil, &BizError{Code: code, Message: envelope.Message, Data: envelope.Data}
+	}
+
+	if len(envelope.Data) == 0 {
+		return []byte("null"), nil
+	}
+	return envelope.Data, nil
+}
+
+// 解析标准响应，并将data解析到target中
+func decodeStandard(responseResult []byte, target interface{}) error {
+	data, err := parseStandard(responseResult, nil)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return &DecodeError{Body: responseResult, Err: err}
+	}
+	return nil
+}
+
+func isSuccessCode(code string) bool {
+	successCodeLock.RLock()
+	defer successCodeLock.RUnlock()
+	for _, successCode := range successCodes {
+		if code == successCode {
+			return true
+		}
+	}
+	return false
+}
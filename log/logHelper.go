@@ -81,6 +81,8 @@ func GetLogger(loggerName string) *logrus.Logger {
 	formatters := &StandardFormatter{}
 	logger.Formatter = formatters
 
+	// 先添加链路信息，再写入文件
+	logger.AddHook(&TraceHook{})
 	lfHook := lfshook.NewHook(lfshook.WriterMap{
 		logrus.DebugLevel: rotateLog(gFilePath, "debug"),
 		logrus.InfoLevel:  rotateLog(gFilePath, "info"),
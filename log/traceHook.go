@@ -0,0 +1,24 @@
+package log
+
+import (
+	"github.com/isyscore/gole/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// TraceHook 通过WithContext记录的日志，添加ctx中span的traceId和spanId，比如：logger.WithContext(c).Info("xxx")
+type TraceHook struct{}
+
+func (hook *TraceHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (hook *TraceHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if span := trace.FromContext(entry.Context); span != nil {
+		entry.Data["traceId"] = span.TraceId()
+		entry.Data["spanId"] = span.SpanId()
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/isyscore/gole/util"
+	"github.com/isyscore/gole/web"
+	"github.com/sirupsen/logrus"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func accessLogEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(web.ResponseHandler(http.StatusNotFound))
+	engine.POST("/login", func(c *gin.Context) {
+		c.JSON(http.StatusOK, map[string]interface{}{"code": "success", "data": map[string]interface{}{"token": "abc", "name": "admin"}})
+	})
+	engine.POST("/form", func(c *gin.Context) {
+		web.FailedOfStandard(c, 500, "登录失败")
+	})
+	engine.GET("/code", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", []byte(`{"code":`+c.Query("code")+`}`))
+	})
+	engine.GET("/items", func(c *gin.Context) {
+		c.String(http.StatusInternalServerError, strings.Repeat("x", 300))
+	})
+	return engine
+}
+
+func accessLogResult(hook *logrusTest.Hook) map[string]interface{} {
+	result := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(hook.LastEntry().Data["result"].(string)), &result)
+	return result
+}
+
+func TestAccessLog(t *testing.T) {
+	logger, hook := logrusTest.NewNullLogger()
+	web.SetAccessLogger(logger)
+	defer web.SetAccessLogger(nil)
+	web.SetAccessLogOptions(&web.AccessLogOptions{
+		Enable:          true,
+		SampleRatio:     1,
+		ShowHead:        true,
+		MaxBodySize:     200,
+		RedactHeaders:   []string{"Authorization"},
+		RedactJsonPaths: []string{"password", "data.token", "items[*].secret"},
+		RedactQueries:   []string{"token", "password"},
+	})
+	defer web.SetAccessLogOptions(nil)
+	engine := accessLogEngine()
+
+	request := httptest.NewRequest(http.MethodPost, "/login?token=123&lang=zh", strings.NewReader(`{"user":"admin","password":"123456","items":[{"secret":"a"},{"secret":"b"}]}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer abc")
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+
+	Equal(t, hook.LastEntry().Level, logrus.InfoLevel)
+	result := accessLogResult(hook)
+	accessRequest := result["request"].(map[string]interface{})
+	Equal(t, result["statusCode"], float64(200), accessRequest["uri"], "/login?lang=zh&token=%2A%2A%2A")
+	Equal(t, util.ObjectToJson(accessRequest["headers"]), `{"authorization":["***"],"content-Type":["application/json"]}`)
+	body := util.ObjectToJson(accessRequest["body"])
+	True(t, strings.Contains(body, `"password":"***"`))
+	True(t, strings.Contains(body, `"user":"admin"`))
+	Equal(t, strings.Count(body, `"secret":"***"`), 2)
+	True(t, strings.Contains(util.ObjectToJson(result["responseBody"]), `"token":"***"`))
+
+	// 表单的参数，业务失败为异常
+	request = httptest.NewRequest(http.MethodPost, "/form", strings.NewReader("user=admin&password=123456"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+	Equal(t, hook.LastEntry().Level, logrus.ErrorLevel)
+	Equal(t, accessLogResult(hook)["request"].(map[string]interface{})["body"], "password=%2A%2A%2A&user=admin")
+
+	// 超过长度截断
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+	result = accessLogResult(hook)
+	Equal(t, result["statusCode"], float64(500), result["responseBody"], strings.Repeat("x", 200)+"...")
+
+	// 排除的状态码按照正常请求记录
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/none", nil))
+	Equal(t, hook.LastEntry().Level, logrus.InfoLevel)
+}
+
+func TestAccessLogSample(t *testing.T) {
+	logger, hook := logrusTest.NewNullLogger()
+	web.SetAccessLogger(logger)
+	defer web.SetAccessLogger(nil)
+	defer web.SetAccessLogOptions(nil)
+	engine := accessLogEngine()
+
+	// 默认只记录异常的请求
+	web.SetAccessLogOptions(nil)
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", nil))
+	Equal(t, len(hook.AllEntries()), 0)
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+	Equal(t, len(hook.AllEntries()), 1)
+
+	// 采样比例为0时正常的请求不记录，异常的请求照常记录
+	hook.Reset()
+	web.SetAccessLogOptions(&web.AccessLogOptions{Enable: true, SampleRatio: 0})
+	for i := 0; i < 10; i++ {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", nil))
+	}
+	Equal(t, len(hook.AllEntries()), 0)
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/form", nil))
+	Equal(t, len(hook.AllEntries()), 1)
+}
+
+// 数字的code和字符串的code一样判断业务是否成功
+func TestAccessLogNumericCode(t *testing.T) {
+	logger, hook := logrusTest.NewNullLogger()
+	web.SetAccessLogger(logger)
+	defer web.SetAccessLogger(nil)
+	web.SetAccessLogOptions(nil)
+	engine := accessLogEngine()
+
+	for _, code := range []string{"0", "200", `"0"`, `"success"`} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/code?code="+url.QueryEscape(code), nil))
+	}
+	Equal(t, len(hook.AllEntries()), 0)
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/code?code=500", nil))
+	Equal(t, len(hook.AllEntries()), 1, hook.LastEntry().Level, logrus.ErrorLevel)
+}
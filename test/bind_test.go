@@ -0,0 +1,194 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/isyscore/gole/util"
+	"github.com/isyscore/gole/web"
+)
+
+type BindAddress struct {
+	City   string `binding:"required"`
+	Street string
+}
+
+type BindOrderReq struct {
+	Id       int    `binding:"required"`
+	TenantId string `header:"X-Tenant-Id" binding:"required"`
+	Name     string `binding:"required,max=5"`
+	Count    int    `binding:"gte=1" message:"数量至少为1"`
+	Tags     []string
+	Level    string `binding:"omitempty,oneof=low high"`
+	Address  BindAddress
+	Cost     float64
+}
+
+type BindPageParam struct {
+	Page int `binding:"omitempty,lte=100"`
+}
+
+type BindUserReq struct {
+	BindPageParam
+	UserName string `form:"user_name" binding:"required"`
+	Age      int    `form:"user_age" binding:"omitempty,gte=18"`
+	NickName string `json:"nick_name"`
+}
+
+type BindUploadReq struct {
+	Name  string `binding:"required"`
+	File  *multipart.FileHeader
+	Files []*multipart.FileHeader `form:"attachment"`
+}
+
+func bindEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/orders/:id", func(c *gin.Context) {
+		var req BindOrderReq
+		if err := web.Bind(c, &req); err != nil {
+			return
+		}
+		body, _ := ioutil.ReadAll(c.Request.Body)
+		web.SuccessOfStandard(c, map[string]interface{}{"req": req, "bodySize": len(body)})
+	})
+	engine.POST("/users", func(c *gin.Context) {
+		var req BindUserReq
+		if err := web.Bind(c, &req); err != nil {
+			return
+		}
+		web.SuccessOfStandard(c, req)
+	})
+	engine.POST("/upload", func(c *gin.Context) {
+		var req BindUploadReq
+		if err := web.Bind(c, &req); err != nil {
+			return
+		}
+		names := []string{req.File.Filename}
+		for _, file := range req.Files {
+			names = append(names, file.Filename)
+		}
+		web.SuccessOfStandard(c, map[string]interface{}{"name": req.Name, "files": names})
+	})
+	return engine
+}
+
+func serveBind(engine *gin.Engine, request *http.Request) (int, map[string]interface{}) {
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+	result := map[string]interface{}{}
+	_ = json.Unmarshal(recorder.Body.Bytes(), &result)
+	return recorder.Code, result
+}
+
+func TestBindJson(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/orders/12?count=3&tags=a&tags=b", strings.NewReader(`{"id":1,"name":"book","count":1,"level":"high","address":{"city":"hz"},"cost":1.5}`))
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	request.Header.Set("X-Tenant-Id", "t1")
+	status, result := serveBind(bindEngine(), request)
+	Equal(t, status, http.StatusOK)
+
+	data := result["data"].(map[string]interface{})
+	req := data["req"].(map[string]interface{})
+	// 路径参数和url参数优先于请求体
+	Equal(t, req["Id"], float64(12), req["Count"], float64(3), req["TenantId"], "t1", req["Name"], "book", req["Cost"], 1.5)
+	Equal(t, req["Address"].(map[string]interface{})["City"], "hz", len(req["Tags"].([]interface{})), 2)
+	// 请求体可以再次读取
+	True(t, data["bodySize"].(float64) > 0)
+}
+
+func TestBindValidate(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/orders/12", strings.NewReader(`{"name":"bookstore","count":0,"level":"middle","address":{}}`))
+	request.Header.Set("Content-Type", "application/json")
+	status, result := serveBind(bindEngine(), request)
+	Equal(t, status, http.StatusBadRequest, result["code"], web.CodeBadRequest, result["message"], "参数错误")
+
+	fields := result["data"].(map[string]interface{})
+	Equal(t, fields["tenantId"], "不能为空", fields["name"], "长度不能大于5", fields["count"], "数量至少为1")
+	Equal(t, fields["level"], "必须为[low high]中的一个", fields["address.city"], "不能为空")
+	Equal(t, len(fields), 5)
+
+	// 类型错误
+	request = httptest.NewRequest(http.MethodPost, "/orders/abc?cost=x", nil)
+	status, result = serveBind(bindEngine(), request)
+	Equal(t, status, http.StatusBadRequest)
+	fields = result["data"].(map[string]interface{})
+	Equal(t, fields["id"], "类型错误", fields["cost"], "类型错误")
+
+	// 请求体格式错误
+	request = httptest.NewRequest(http.MethodPost, "/orders/12", strings.NewReader(`[1]`))
+	request.Header.Set("Content-Type", "application/json")
+	status, result = serveBind(bindEngine(), request)
+	Equal(t, status, http.StatusBadRequest)
+	True(t, result["data"].(map[string]interface{})["body"] != nil)
+}
+
+func TestBindForm(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/orders/12", strings.NewReader("name=pen&count=2&tags=x&address=ignored"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("X-Tenant-Id", "t2")
+	status, result := serveBind(bindEngine(), request)
+	Equal(t, status, http.StatusBadRequest)
+	Equal(t, result["data"].(map[string]interface{})["address.city"], "不能为空")
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	_ = writer.WriteField("name", "report")
+	file, _ := writer.CreateFormFile("file", "a.txt")
+	_, _ = file.Write([]byte("a"))
+	file, _ = writer.CreateFormFile("attachment", "b.txt")
+	_, _ = file.Write([]byte("b"))
+	file, _ = writer.CreateFormFile("attachment", "c.txt")
+	_, _ = file.Write([]byte("c"))
+	_ = writer.Close()
+
+	request = httptest.NewRequest(http.MethodPost, "/upload", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	status, result = serveBind(bindEngine(), request)
+	Equal(t, status, http.StatusOK)
+	data := result["data"].(map[string]interface{})
+	Equal(t, data["name"], "report", util.ObjectToJson(data["files"]), `["a.txt","b.txt","c.txt"]`)
+}
+
+// form、json标签指定的参数名
+func TestBindTagName(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/users?user_age=18", strings.NewReader("user_name=gole"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	status, result := serveBind(bindEngine(), request)
+	Equal(t, status, http.StatusOK)
+	req := result["data"].(map[string]interface{})
+	Equal(t, req["UserName"], "gole", req["Age"], float64(18))
+
+	request = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"user_name":"gole","nick_name":"g"}`))
+	request.Header.Set("Content-Type", "application/json")
+	status, result = serveBind(bindEngine(), request)
+	Equal(t, status, http.StatusOK)
+	req = result["data"].(map[string]interface{})
+	Equal(t, req["UserName"], "gole", req["nick_name"], "g")
+
+	// 类型错误和校验失败使用相同的参数名
+	request = httptest.NewRequest(http.MethodPost, "/users?user_age=x", strings.NewReader(`{}`))
+	request.Header.Set("Content-Type", "application/json")
+	status, result = serveBind(bindEngine(), request)
+	Equal(t, status, http.StatusBadRequest, result["data"].(map[string]interface{})["user_age"], "类型错误")
+
+	request = httptest.NewRequest(http.MethodPost, "/users?user_age=3&page=101", strings.NewReader(`{}`))
+	request.Header.Set("Content-Type", "application/json")
+	status, result = serveBind(bindEngine(), request)
+	Equal(t, status, http.StatusBadRequest)
+	fields := result["data"].(map[string]interface{})
+	Equal(t, fields["user_age"], "不能小于18", fields["user_name"], "不能为空", fields["page"], "不能大于100", len(fields), 3)
+
+	// 匿名嵌入的结构体的字段平铺
+	request = httptest.NewRequest(http.MethodPost, "/users?page=2", strings.NewReader(`{"user_name":"gole"}`))
+	request.Header.Set("Content-Type", "application/json")
+	status, result = serveBind(bindEngine(), request)
+	Equal(t, status, http.StatusOK, result["data"].(map[string]interface{})["Page"], float64(2))
+}
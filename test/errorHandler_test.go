@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	goleHttp "github.com/isyscore/gole/http"
+	"github.com/isyscore/gole/util"
+	"github.com/isyscore/gole/web"
+	"github.com/sirupsen/logrus"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+)
+
+const codeOrderNotFound = "10404"
+
+func init() {
+	web.RegisterErrorCode(codeOrderNotFound, "订单%v不存在", http.StatusNotFound)
+}
+
+func errorHandlerEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(web.ErrorHandler())
+	engine.GET("/orders/:id", web.Handle(func(c *gin.Context) error {
+		return web.ErrorOf(codeOrderNotFound, c.Param("id")).WithData(map[string]interface{}{"id": c.Param("id")})
+	}))
+	engine.GET("/wrapped", web.Handle(func(c *gin.Context) error {
+		return fmt.Errorf("query order: %w", web.NewBizError("10001", "库存不足"))
+	}))
+	engine.GET("/error", web.Handle(func(c *gin.Context) error {
+		return errors.New("connection refused")
+	}))
+	engine.GET("/panic", func(c *gin.Context) {
+		var data map[string]int
+		data["a"] = 1
+	})
+	engine.GET("/panicBiz", func(c *gin.Context) {
+		panic(web.ErrorOf(web.CodeForbidden))
+	})
+	engine.GET("/panicWritten", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("after written")
+	})
+	engine.GET("/ok", web.Handle(func(c *gin.Context) error {
+		web.SuccessOfStandard(c, "ok")
+		return nil
+	}))
+	return engine
+}
+
+func serveError(engine *gin.Engine, path string) (int, map[string]interface{}) {
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	result := map[string]interface{}{}
+	_ = json.Unmarshal(recorder.Body.Bytes(), &result)
+	return recorder.Code, result
+}
+
+func TestErrorHandler(t *testing.T) {
+	logger, hook := logrusTest.NewNullLogger()
+	web.SetAccessLogger(logger)
+	defer web.SetAccessLogger(nil)
+	engine := errorHandlerEngine()
+
+	status, result := serveError(engine, "/orders/12")
+	Equal(t, status, http.StatusNotFound, result["code"], codeOrderNotFound, result["message"], "订单12不存在")
+	Equal(t, result["data"].(map[string]interface{})["id"], "12")
+
+	status, result = serveError(engine, "/wrapped")
+	Equal(t, status, http.StatusOK, result["code"], "10001", result["message"], "库存不足", result["data"], nil)
+
+	// 其他的错误不返回错误信息，记录日志
+	status, result = serveError(engine, "/error")
+	Equal(t, status, http.StatusInternalServerError, result["code"], web.CodeInternal, result["message"], "服务内部错误")
+	Equal(t, hook.LastEntry().Level, logrus.ErrorLevel)
+	True(t, strings.Contains(hook.LastEntry().Message, "connection refused"))
+
+	// panic记录堆栈
+	hook.Reset()
+	status, result = serveError(engine, "/panic")
+	Equal(t, status, http.StatusInternalServerError, result["code"], web.CodeInternal)
+	Equal(t, len(hook.AllEntries()), 1)
+	True(t, strings.Contains(hook.LastEntry().Message, "assignment to entry in nil map"))
+	True(t, strings.Contains(hook.LastEntry().Data["stack"].(string), "errorHandler_test.go"))
+
+	status, result = serveError(engine, "/panicBiz")
+	Equal(t, status, http.StatusForbidden, result["code"], web.CodeForbidden, result["message"], "没有权限")
+
+	// 响应已经写出后的panic只记录日志
+	hook.Reset()
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panicWritten", nil))
+	Equal(t, recorder.Code, http.StatusOK, recorder.Body.String(), "partial", len(hook.AllEntries()), 1)
+
+	status, result = serveError(engine, "/ok")
+	Equal(t, status, http.StatusOK, result["code"], "success", result["data"], "ok")
+}
+
+// 服务端返回的code和客户端解析出的code类型一致
+func TestErrorCodeRoundTrip(t *testing.T) {
+	server := httptest.NewServer(errorHandlerEngine())
+	defer server.Close()
+
+	var data interface{}
+	_, err := goleHttp.New().URL(server.URL + "/wrapped").IntoStandard(&data).Do(context.Background())
+	var bizError *goleHttp.BizError
+	True(t, errors.As(err, &bizError))
+	Equal(t, bizError.Code, web.NewBizError("10001", "").Code, bizError.Message, "库存不足")
+}
+
+func TestErrorCode(t *testing.T) {
+	errorCode, ok := web.ErrorCodeOf(codeOrderNotFound)
+	True(t, ok)
+	Equal(t, errorCode.Message, "订单%v不存在", errorCode.HttpStatus, http.StatusNotFound)
+
+	// 相同的注册可以重复，不同的会panic
+	web.RegisterErrorCode(codeOrderNotFound, "订单%v不存在", http.StatusNotFound)
+	func() {
+		defer func() {
+			True(t, recover() != nil)
+		}()
+		web.RegisterErrorCode(codeOrderNotFound, "订单不存在", http.StatusNotFound)
+	}()
+
+	// 数字的code按照数值排序
+	codes := web.ErrorCodes()
+	for i := 1; i < len(codes); i++ {
+		True(t, util.ToInt(codes[i-1].Code) < util.ToInt(codes[i].Code))
+	}
+
+	bizError := web.ErrorOf("99999")
+	Equal(t, bizError.HttpStatus, http.StatusInternalServerError)
+
+	cause := errors.New("timeout")
+	bizError = web.ErrorOf(web.CodeInternal).WithCause(cause)
+	True(t, errors.Is(bizError, cause))
+	Equal(t, bizError.Error(), "code=500, message=服务内部错误, cause=timeout")
+}
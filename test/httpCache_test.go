@@ -0,0 +1,248 @@
+package test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goleHttp "github.com/isyscore/gole/http"
+)
+
+func newCacheClient() (*goleHttp.Cache, *goleHttp.HttpClient) {
+	cache := goleHttp.NewCache(goleHttp.NewMemoryCacheStore(100))
+	return cache, goleHttp.NewClient(nil, cache.Interceptor())
+}
+
+func cacheGet(client *goleHttp.HttpClient, url string, header http.Header) (*goleHttp.Response, error) {
+	return goleHttp.New().Client(client).URL(url).Headers(header).Do(context.Background())
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	cache, client := newCacheClient()
+	for i := 0; i < 3; i++ {
+		response, err := cacheGet(client, server.URL+"/dict", nil)
+		if err != nil {
+			Err(t, err)
+			return
+		}
+		Equal(t, response.String(), "data")
+	}
+	Equal(t, atomic.LoadInt32(&count), int32(1), cache.Stats().Hits, int64(2), cache.Stats().Misses, int64(1))
+
+	// 请求no-cache时重新请求
+	_, _ = cacheGet(client, server.URL+"/dict", http.Header{"Cache-Control": {"no-cache"}})
+	Equal(t, atomic.LoadInt32(&count), int32(2))
+
+	// 其他方法成功后删除缓存
+	_, _ = goleHttp.New().Client(client).Method(http.MethodPut).URL(server.URL + "/dict").JSON("a").Do(context.Background())
+	_, _ = cacheGet(client, server.URL+"/dict", nil)
+	Equal(t, atomic.LoadInt32(&count), int32(4))
+}
+
+func TestCacheETag(t *testing.T) {
+	var count, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	cache, client := newCacheClient()
+	for i := 0; i < 3; i++ {
+		response, err := cacheGet(client, server.URL, nil)
+		if err != nil {
+			Err(t, err)
+			return
+		}
+		Equal(t, response.StatusCode, 200, response.String(), "data")
+	}
+	Equal(t, atomic.LoadInt32(&count), int32(3), atomic.LoadInt32(&notModified), int32(2))
+	Equal(t, cache.Stats(), goleHttp.CacheStats{Hits: 2, Misses: 1, Revalidated: 2})
+}
+
+func TestCacheLastModified(t *testing.T) {
+	lastModified := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Header().Set("Last-Modified", lastModified)
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	cache, client := newCacheClient()
+	for i := 0; i < 2; i++ {
+		response, err := cacheGet(client, server.URL, nil)
+		if err != nil {
+			Err(t, err)
+			return
+		}
+		Equal(t, response.String(), "data")
+	}
+	Equal(t, atomic.LoadInt32(&count), int32(2), cache.Stats().Revalidated, int64(1))
+}
+
+func TestCacheNotCacheable(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		switch r.URL.Path {
+		case "/noStore":
+			w.Header().Set("Cache-Control", "no-store, max-age=60")
+		case "/error":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+			_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+		}
+	}))
+	defer server.Close()
+
+	_, client := newCacheClient()
+	_, _ = cacheGet(client, server.URL+"/noStore", nil)
+	_, _ = cacheGet(client, server.URL+"/noStore", nil)
+	_, _ = cacheGet(client, server.URL+"/error", nil)
+	_, _ = cacheGet(client, server.URL+"/error", nil)
+	Equal(t, atomic.LoadInt32(&count), int32(4))
+
+	// Vary的请求头不同的响应分别缓存
+	atomic.StoreInt32(&count, 0)
+	for _, language := range []string{"zh", "en", "zh", "en"} {
+		response, err := cacheGet(client, server.URL+"/vary", http.Header{"Accept-Language": {language}})
+		if err != nil {
+			Err(t, err)
+			return
+		}
+		Equal(t, response.String(), language)
+	}
+	Equal(t, atomic.LoadInt32(&count), int32(2))
+}
+
+func TestCachePrivate(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/public":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		default:
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		_, _ = w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	_, client := newCacheClient()
+	authorization := http.Header{"Authorization": {"Bearer token"}}
+	cookie := http.Header{"Cookie": {"session=1"}}
+	for i := 0; i < 2; i++ {
+		_, _ = cacheGet(client, server.URL+"/private", nil)
+		_, _ = cacheGet(client, server.URL+"/auth", authorization)
+		_, _ = cacheGet(client, server.URL+"/cookie", cookie)
+	}
+	Equal(t, atomic.LoadInt32(&count), int32(6))
+
+	// public的响应可以缓存
+	atomic.StoreInt32(&count, 0)
+	_, _ = cacheGet(client, server.URL+"/public", authorization)
+	_, _ = cacheGet(client, server.URL+"/public", authorization)
+	Equal(t, atomic.LoadInt32(&count), int32(1))
+}
+
+// 流式请求不经过缓存
+func TestCacheStream(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	cache, client := newCacheClient()
+	for i := 0; i < 2; i++ {
+		stream, err := goleHttp.New().Client(client).URL(server.URL).Stream(context.Background())
+		if err != nil {
+			Err(t, err)
+			return
+		}
+		content, _ := ioutil.ReadAll(stream.Body)
+		_ = stream.Body.Close()
+		Equal(t, string(content), "data")
+	}
+	Equal(t, atomic.LoadInt32(&count), int32(2), cache.Stats(), goleHttp.CacheStats{})
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	ctx := context.Background()
+	store := goleHttp.NewMemoryCacheStore(2)
+	_ = store.Set(ctx, "a", &goleHttp.CacheEntry{Body: []byte("a")}, time.Minute)
+	_ = store.Set(ctx, "b", &goleHttp.CacheEntry{Body: []byte("b")}, time.Minute)
+	// 访问a之后b为最久没有使用的
+	_, _ = store.Get(ctx, "a")
+	_ = store.Set(ctx, "c", &goleHttp.CacheEntry{Body: []byte("c")}, time.Minute)
+
+	entry, _ := store.Get(ctx, "b")
+	True(t, entry == nil)
+	entry, _ = store.Get(ctx, "a")
+	Equal(t, string(entry.Body), "a", store.Len(), 2)
+
+	// 过期
+	_ = store.Set(ctx, "d", &goleHttp.CacheEntry{Body: []byte("d")}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	entry, _ = store.Get(ctx, "d")
+	True(t, entry == nil)
+}
+
+func TestCacheOfStandard(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte(`{"code":"success","message":"成功","data":{"name":"gole"}}`))
+	}))
+	defer server.Close()
+
+	cache := goleHttp.NewCache(goleHttp.NewMemoryCacheStore(10))
+	goleHttp.AddInterceptor(cache.Interceptor())
+	defer goleHttp.ClearInterceptors()
+
+	for i := 0; i < 2; i++ {
+		data, err := goleHttp.GetOfStandard(server.URL, nil, nil)
+		if err != nil {
+			Err(t, err)
+			return
+		}
+		Equal(t, string(data), `{"name":"gole"}`)
+	}
+	Equal(t, atomic.LoadInt32(&count), int32(1), cache.Stats().Hits, int64(1))
+}
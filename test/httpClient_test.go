@@ -0,0 +1,94 @@
+package test
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isyscore/gole/config"
+	goleHttp "github.com/isyscore/gole/http"
+)
+
+func TestNamedClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(500 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(r.URL.Path + "|" + r.Header.Get("X-App") + "|" + r.Header.Get("X-Token")))
+	}))
+	defer server.Close()
+
+	billing, err := goleHttp.NewClientWithConfig(config.HttpClientConfig{
+		BaseUrl: server.URL + "/billing/",
+		Headers: map[string]string{"X-App": "gole", "X-Token": "default"},
+		Timeout: 100,
+	})
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	goleHttp.RegisterClient("billing", billing)
+
+	response, err := goleHttp.Client("billing").New().URL("/orders").Header("X-Token", "override").Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, response.String(), "/billing/orders|gole|override", goleHttp.Client("billing").Name(), "billing")
+
+	// 绝对地址不拼接基础地址
+	response, _ = goleHttp.Client("billing").New().URL(server.URL + "/other").Do(context.Background())
+	Equal(t, response.String(), "/other|gole|default")
+
+	// 客户端的超时
+	_, err = goleHttp.Client("billing").New().URL(server.URL + "/slow").Do(context.Background())
+	True(t, err != nil)
+
+	// 没有配置的客户端使用默认配置
+	response, _ = goleHttp.Client("none").New().URL(server.URL + "/none").Do(context.Background())
+	Equal(t, response.String(), "/none||")
+	True(t, goleHttp.Client("none") == goleHttp.Client("none"))
+}
+
+func TestNamedClientTls(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tls"))
+	}))
+	defer server.Close()
+
+	dir, _ := ioutil.TempDir("", "gole-tls")
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	_ = ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644)
+
+	withCa, err := goleHttp.NewClientWithConfig(config.HttpClientConfig{Tls: config.HttpTlsConfig{CaFile: caFile}})
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	response, err := withCa.New().URL(server.URL).Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, response.String(), "tls")
+
+	insecure, _ := goleHttp.NewClientWithConfig(config.HttpClientConfig{Tls: config.HttpTlsConfig{InsecureSkipVerify: true}})
+	response, err = insecure.New().URL(server.URL).Do(context.Background())
+	True(t, err == nil)
+
+	plain, _ := goleHttp.NewClientWithConfig(config.HttpClientConfig{})
+	_, err = plain.New().URL(server.URL).Do(context.Background())
+	True(t, err != nil)
+
+	_, err = goleHttp.NewClientWithConfig(config.HttpClientConfig{Tls: config.HttpTlsConfig{CaFile: filepath.Join(dir, "none.pem")}})
+	True(t, err != nil)
+	_, err = goleHttp.NewClientWithConfig(config.HttpClientConfig{Proxy: "://bad"})
+	True(t, err != nil)
+}
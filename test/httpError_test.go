@@ -0,0 +1,83 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	goleHttp "github.com/isyscore/gole/http"
+)
+
+func newStatusServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"code":0,"data":"created"}`))
+		case "/noContent":
+			w.WriteHeader(http.StatusNoContent)
+		case "/notFound":
+			w.Header().Set("X-Error", "missing")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+		case "/text":
+			_, _ = w.Write([]byte("not json"))
+		case "/biz":
+			_, _ = w.Write([]byte(`{"code":"FAILED","message":"biz failed"}`))
+		}
+	}))
+}
+
+func TestStatusError(t *testing.T) {
+	server := newStatusServer()
+	defer server.Close()
+
+	// 2xx都是成功
+	data, err := goleHttp.PostSimpleOfStandard(server.URL+"/created", nil)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, string(data), `"created"`)
+	_, err = goleHttp.DeleteSimple(server.URL + "/noContent")
+	True(t, err == nil)
+
+	_, err = goleHttp.Get(server.URL+"/notFound", nil, map[string]string{"a": "1"})
+	var statusError *goleHttp.StatusError
+	True(t, errors.As(err, &statusError))
+	Equal(t, statusError.StatusCode, 404, statusError.Method, "GET", statusError.URL, server.URL+"/notFound?a=1")
+	Equal(t, statusError.Header.Get("X-Error"), "missing", string(statusError.Body), `{"error":"not found"}`)
+}
+
+func TestTransportAndDecodeError(t *testing.T) {
+	server := newStatusServer()
+	defer server.Close()
+
+	_, err := goleHttp.GetSimple("http://127.0.0.1:1/none")
+	var transportError *goleHttp.TransportError
+	True(t, errors.As(err, &transportError))
+	Equal(t, transportError.URL, "http://127.0.0.1:1/none")
+
+	_, err = goleHttp.New().URL(server.URL + "/notFound").Do(goleHttp.WithTimeout(context.Background(), time.Nanosecond))
+	True(t, errors.As(err, &transportError))
+	True(t, errors.Is(err, context.DeadlineExceeded))
+
+	var target map[string]interface{}
+	_, err = goleHttp.New().URL(server.URL + "/text").Into(&target).Do(context.Background())
+	var decodeError *goleHttp.DecodeError
+	True(t, errors.As(err, &decodeError))
+	Equal(t, decodeError.URL, server.URL+"/text", string(decodeError.Body), "not json")
+
+	_, err = goleHttp.GetSimpleOfStandard(server.URL + "/text")
+	True(t, errors.As(err, &decodeError))
+
+	err = goleHttp.GetOfStandardInto(server.URL+"/biz", &target)
+	var bizError *goleHttp.BizError
+	True(t, errors.As(err, &bizError))
+	Equal(t, bizError.Code, "FAILED")
+	True(t, strings.Contains(err.Error(), "biz failed"))
+}
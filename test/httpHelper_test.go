@@ -0,0 +1,108 @@
+package test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	goleHttp "github.com/isyscore/gole/http"
+)
+
+func TestGetCtxTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+			_, _ = w.Write([]byte("ok"))
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	_, err := goleHttp.GetCtx(goleHttp.WithTimeout(context.Background(), 100*time.Millisecond), server.URL, nil, nil)
+	True(t, err != nil)
+	True(t, time.Since(start) < time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	start = time.Now()
+	_, err = goleHttp.PostCtx(ctx, server.URL, nil, nil, map[string]string{"a": "b"})
+	True(t, err != nil)
+	True(t, time.Since(start) < time.Second)
+}
+
+func TestGetCtx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":0,"message":"success","data":{"name":"` + r.URL.Query().Get("name") + `"}}`))
+	}))
+	defer server.Close()
+
+	data, err := goleHttp.GetOfStandardCtx(goleHttp.WithTimeout(context.Background(), time.Second), server.URL, nil, map[string]string{"name": "gole"})
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, string(data), `{"name":"gole"}`)
+}
+
+func TestHead(t *testing.T) {
+	modTime := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.URL.Path == "/none" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, r, "a.txt", modTime, strings.NewReader(strings.Repeat("a", 1024)))
+	}))
+	defer server.Close()
+
+	response, err := goleHttp.HeadOfResponse(context.Background(), server.URL+"/a.txt", nil, nil)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	lastModified, err := response.LastModified()
+	True(t, err == nil)
+	Equal(t, methods[0], "HEAD", response.StatusCode, 200, response.ContentLength, int64(1024), len(response.Body), 0)
+	True(t, lastModified.Equal(modTime))
+
+	True(t, goleHttp.HeadSimple(server.URL+"/a.txt") == nil)
+	Equal(t, methods[1], "HEAD")
+	True(t, goleHttp.HeadSimple(server.URL+"/none") != nil)
+}
+
+func TestOptionsAndDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Allow", "GET, POST, OPTIONS")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write([]byte(r.Method + " " + string(body)))
+	}))
+	defer server.Close()
+
+	response, err := goleHttp.OptionsSimple(server.URL)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, response.StatusCode, 204, response.Header.Get("Allow"), "GET, POST, OPTIONS")
+	_, err = response.LastModified()
+	True(t, err != nil)
+
+	response, _ = goleHttp.Do(context.Background(), "PROPFIND", server.URL, nil, nil, map[string]int{"a": 1})
+	Equal(t, response.String(), `PROPFIND {"a":1}`)
+	response, _ = goleHttp.Do(context.Background(), "purge", server.URL, nil, nil, nil)
+	Equal(t, response.String(), "PURGE ")
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	goleHttp "github.com/isyscore/gole/http"
+	"github.com/sirupsen/logrus"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+)
+
+func newTestLogger(options *goleHttp.LogOptions) *logrusTest.Hook {
+	logger, hook := logrusTest.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	goleHttp.SetLogger(logger)
+	goleHttp.SetLogOptions(options)
+	return hook
+}
+
+func resetTestLogger() {
+	goleHttp.SetLogger(nil)
+	goleHttp.SetLogOptions(nil)
+}
+
+func TestLogCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	defer resetTestLogger()
+
+	// 默认info级别，成功的调用不记录
+	hook := newTestLogger(nil)
+	_, _ = goleHttp.GetSimple(server.URL)
+	Equal(t, len(hook.AllEntries()), 0)
+
+	options := goleHttp.DefaultLogOptions()
+	options.Level = logrus.DebugLevel
+	hook = newTestLogger(options)
+	_, _ = goleHttp.GetSimple(server.URL + "/ok")
+	entry := hook.LastEntry()
+	Equal(t, entry.Level, logrus.DebugLevel, entry.Data["method"], "GET", entry.Data["url"], server.URL+"/ok", entry.Data["status"], 200, entry.Data["attempt"], 1)
+	_, ok := entry.Data["latency"].(time.Duration)
+	True(t, ok)
+
+	// 重试的每次请求都记录
+	hook.Reset()
+	policy := &goleHttp.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, StatusCodes: []int{503}}
+	_, _ = goleHttp.New().URL(server.URL + "/error").Retry(policy).Do(context.Background())
+	entries := hook.AllEntries()
+	Equal(t, len(entries), 3)
+	Equal(t, entries[0].Level, logrus.ErrorLevel, entries[0].Data["status"], 503, entries[0].Data["attempt"], 1)
+	Equal(t, entries[1].Level, logrus.WarnLevel, entries[1].Message, "retry request", entries[1].Data["attempt"], 2)
+	Equal(t, entries[2].Level, logrus.ErrorLevel, entries[2].Data["attempt"], 2)
+
+	// 网络错误
+	hook.Reset()
+	_, _ = goleHttp.GetSimple("http://127.0.0.1:1/unreachable")
+	entry = hook.LastEntry()
+	Equal(t, entry.Level, logrus.ErrorLevel, entry.Message, "http request failed")
+	True(t, entry.Data["err"] != nil)
+}
+
+func TestLogBodyRedact(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"token":"abc","items":[{"password":"p"}],"name":"` + strings.Repeat("a", 100) + `"}`))
+	}))
+	defer server.Close()
+	defer resetTestLogger()
+
+	options := goleHttp.DefaultLogOptions()
+	options.Level = logrus.DebugLevel
+	options.LogBody = true
+	options.MaxBodySize = 80
+	options.RedactFields = []string{"password", "token"}
+	hook := newTestLogger(options)
+
+	header := http.Header{"Authorization": {"Bearer secret"}}
+	_, _ = goleHttp.New().Method(http.MethodPost).URL(server.URL).Headers(header).Query("token", "abc").Query("page", "1").
+		JSON(map[string]string{"user": "gole", "password": "123"}).Do(context.Background())
+	entry := hook.LastEntry()
+
+	Equal(t, entry.Data["url"], server.URL+"?page=1&token=%2A%2A%2A")
+	Equal(t, entry.Data["requestHeader"].(http.Header).Get("Authorization"), "***")
+	Equal(t, entry.Data["requestBody"], `{"password":"***","user":"gole"}`)
+	responseBody := entry.Data["responseBody"].(string)
+	True(t, strings.HasPrefix(responseBody, `{"items":[{"password":"***"}],"name":"aaa`))
+	True(t, strings.HasSuffix(responseBody, "..."))
+	Equal(t, len(responseBody), 83)
+	False(t, strings.Contains(responseBody, "abc"))
+
+	// 表单的请求体
+	_, _ = goleHttp.New().Method(http.MethodPost).URL(server.URL).Form("user", "gole").Form("password", "123").Do(context.Background())
+	Equal(t, hook.LastEntry().Data["requestBody"], "password=%2A%2A%2A&user=gole")
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	goleHttp "github.com/isyscore/gole/http"
+)
+
+func TestUrlWithQuery(t *testing.T) {
+	act, _ := goleHttp.UrlWithQuery("http://localhost/api", url.Values{"b": {"2"}, "a": {"x y", "&"}})
+	Equal(t, act, "http://localhost/api?a=x+y&a=%26&b=2")
+
+	act, _ = goleHttp.UrlWithQuery("http://localhost/api?c=3&a=0", url.Values{"a": {"1"}})
+	Equal(t, act, "http://localhost/api?a=0&a=1&c=3")
+
+	act, _ = goleHttp.UrlWithQuery("http://localhost/api?c=3", nil)
+	Equal(t, act, "http://localhost/api?c=3")
+
+	_, err := goleHttp.UrlWithQuery("http://local host/%zz", url.Values{"a": {"1"}})
+	True(t, err != nil)
+}
+
+func TestUrlWithPathParam(t *testing.T) {
+	act, _ := goleHttp.UrlWithPathParam("http://localhost/users/{id}/orders/{orderId}", map[string]string{"id": "a/b c", "orderId": "1"})
+	Equal(t, act, "http://localhost/users/a%2Fb%20c/orders/1")
+
+	_, err := goleHttp.UrlWithPathParam("http://localhost/users/{id}", nil)
+	True(t, err != nil)
+}
+
+func TestRequestQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.EscapedPath() + "?" + r.URL.RawQuery))
+	}))
+	defer server.Close()
+
+	response, err := goleHttp.New().URL(server.URL+"/users/{id}?sort=name").PathParam("id", "7").Query("tag", "a", "b").QueryValues(url.Values{"q": {"中文"}}).Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, response.String(), "/users/7?q=%E4%B8%AD%E6%96%87&sort=name&tag=a&tag=b")
+
+	data, _ := goleHttp.Get(server.URL+"/list?page=1", nil, map[string]string{"name": "a=b", "size": "10"})
+	Equal(t, string(data), "/list?name=a%3Db&page=1&size=10")
+}
@@ -0,0 +1,104 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	goleHttp "github.com/isyscore/gole/http"
+)
+
+type echoResult struct {
+	Method      string            `json:"method"`
+	Path        string            `json:"path"`
+	Query       string            `json:"query"`
+	ContentType string            `json:"contentType"`
+	Token       string            `json:"token"`
+	Body        string            `json:"body"`
+	Form        map[string]string `json:"form"`
+}
+
+func newEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.Header().Set("X-Reason", "broken")
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("broken"))
+			return
+		}
+
+		result := echoResult{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, ContentType: r.Header.Get("Content-Type"), Token: r.Header.Get("token")}
+		if strings.HasPrefix(result.ContentType, "multipart/form-data") {
+			_ = r.ParseMultipartForm(1 << 20)
+			result.Form = map[string]string{"name": r.FormValue("name")}
+			if file, header, err := r.FormFile("file"); err == nil {
+				content, _ := ioutil.ReadAll(file)
+				result.Form[header.Filename] = string(content)
+			}
+		} else {
+			body, _ := ioutil.ReadAll(r.Body)
+			result.Body = string(body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(result)
+	}))
+}
+
+func TestRequestJSON(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+
+	result := echoResult{}
+	response, err := goleHttp.New().Method("post").URL(server.URL+"/users").Header("token", "abc").Query("page", "1").JSON(map[string]int{"age": 1}).Into(&result).Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, response.StatusCode, 200, response.Header.Get("Content-Type"), "application/json")
+	Equal(t, result.Method, "POST", result.Path, "/users", result.Query, "page=1", result.Token, "abc")
+	Equal(t, result.ContentType, "application/json", result.Body, `{"age":1}`)
+}
+
+func TestRequestFormAndMultipart(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+
+	result := echoResult{}
+	_, err := goleHttp.New().Method(http.MethodPut).URL(server.URL).Form("a", "1").Form("b", "x y").Into(&result).Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, result.ContentType, "application/x-www-form-urlencoded", result.Body, "a=1&b=x+y")
+
+	_, err = goleHttp.New().Method(http.MethodPost).URL(server.URL).MultipartField("name", "gole").MultipartFile("file", "a.txt", strings.NewReader("content")).Into(&result).Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, result.Form["name"], "gole", result.Form["a.txt"], "content")
+
+	response, _ := goleHttp.New().Method(http.MethodPatch).URL(server.URL).Body(strings.NewReader("raw"), "text/plain").Do(context.Background())
+	_ = response.Decode(&result)
+	Equal(t, result.Method, "PATCH", result.ContentType, "text/plain", result.Body, "raw")
+}
+
+func TestRequestFailed(t *testing.T) {
+	server := newEchoServer()
+	defer server.Close()
+
+	response, err := goleHttp.New().URL(server.URL + "/fail").Do(context.Background())
+	True(t, err != nil)
+	Equal(t, response.StatusCode, 500, response.Header.Get("X-Reason"), "broken", response.String(), "broken")
+
+	data, err := goleHttp.DeleteSimple(server.URL + "/users")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	True(t, strings.Contains(string(data), `"method":"DELETE"`))
+}
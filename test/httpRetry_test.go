@@ -0,0 +1,164 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goleHttp "github.com/isyscore/gole/http"
+)
+
+func newFlakyServer(failTimes int32, failCode int, retryAfter string) (*httptest.Server, *int32) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= failTimes {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(failCode)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	return server, &count
+}
+
+func TestRetry(t *testing.T) {
+	policy := &goleHttp.RetryPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, Jitter: 0.5, StatusCodes: []int{503}}
+
+	server, count := newFlakyServer(2, 503, "")
+	defer server.Close()
+	response, err := goleHttp.New().URL(server.URL).Retry(policy).Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, response.String(), "ok", atomic.LoadInt32(count), int32(3))
+
+	// 非幂等的方法不重试
+	atomic.StoreInt32(count, 0)
+	_, err = goleHttp.New().Method("POST").URL(server.URL).JSON("a").Retry(policy).Do(context.Background())
+	True(t, err != nil)
+	Equal(t, atomic.LoadInt32(count), int32(1))
+
+	// 不在重试列表中的状态码不重试
+	server500, count500 := newFlakyServer(1, 500, "")
+	defer server500.Close()
+	_, err = goleHttp.New().URL(server500.URL).Retry(policy).Do(context.Background())
+	True(t, err != nil)
+	Equal(t, atomic.LoadInt32(count500), int32(1))
+
+	// 全局策略，请求体可以重放
+	goleHttp.SetRetryPolicy(&goleHttp.RetryPolicy{MaxAttempts: 2, StatusCodes: []int{503}, RetryNonIdempotent: true})
+	defer goleHttp.SetRetryPolicy(nil)
+	server2, count2 := newFlakyServer(1, 503, "")
+	defer server2.Close()
+	data, err := goleHttp.PostSimple(server2.URL, map[string]string{"a": "b"})
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, string(data), "ok", atomic.LoadInt32(count2), int32(2))
+}
+
+func TestRetryAfter(t *testing.T) {
+	server, count := newFlakyServer(1, 429, "1")
+	defer server.Close()
+
+	start := time.Now()
+	policy := &goleHttp.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, StatusCodes: []int{429}}
+	_, err := goleHttp.New().URL(server.URL).Retry(policy).Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, atomic.LoadInt32(count), int32(2))
+	True(t, time.Since(start) >= time.Second)
+
+	// 等待时间超过调用的超时则直接返回
+	atomic.StoreInt32(count, 0)
+	start = time.Now()
+	_, err = goleHttp.New().URL(server.URL).Retry(policy).Do(goleHttp.WithTimeout(context.Background(), 100*time.Millisecond))
+	True(t, err != nil)
+	True(t, time.Since(start) < time.Second)
+}
+
+// 没有最大回退时间时回退时间同样翻倍
+func TestRetryBackoffUncapped(t *testing.T) {
+	policy := &goleHttp.RetryPolicy{MaxAttempts: 4, InitialBackoff: 20 * time.Millisecond, StatusCodes: []int{503}}
+
+	server, count := newFlakyServer(3, 503, "")
+	defer server.Close()
+	start := time.Now()
+	response, err := goleHttp.New().URL(server.URL).Retry(policy).Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	// 20ms + 40ms + 80ms
+	Equal(t, response.String(), "ok", atomic.LoadInt32(count), int32(4))
+	True(t, time.Since(start) >= 140*time.Millisecond)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	goleHttp.SetCircuitBreakerPolicy(&goleHttp.CircuitBreakerPolicy{FailureThreshold: 2, OpenTimeout: 200 * time.Millisecond, HalfOpenRequests: 1})
+	defer goleHttp.SetCircuitBreakerPolicy(nil)
+
+	server, count := newFlakyServer(3, 500, "")
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err := goleHttp.GetSimple(server.URL)
+		True(t, err != nil)
+	}
+
+	// 熔断中，请求不发出
+	_, err := goleHttp.GetSimple(server.URL)
+	var openError *goleHttp.CircuitOpenError
+	True(t, errors.As(err, &openError))
+	Equal(t, atomic.LoadInt32(count), int32(2))
+
+	// 半开状态探测失败，重新熔断
+	time.Sleep(250 * time.Millisecond)
+	_, err = goleHttp.GetSimple(server.URL)
+	True(t, err != nil && !errors.As(err, &openError))
+	_, err = goleHttp.GetSimple(server.URL)
+	True(t, errors.As(err, &openError))
+
+	// 半开状态探测成功，恢复
+	time.Sleep(250 * time.Millisecond)
+	data, err := goleHttp.GetSimple(server.URL)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, string(data), "ok")
+	data, _ = goleHttp.GetSimple(server.URL)
+	Equal(t, string(data), "ok", atomic.LoadInt32(count), int32(5))
+}
+
+func TestCircuitBreakerStream(t *testing.T) {
+	goleHttp.SetCircuitBreakerPolicy(&goleHttp.CircuitBreakerPolicy{FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenRequests: 1})
+	defer goleHttp.SetCircuitBreakerPolicy(nil)
+
+	server, count := newFlakyServer(2, 500, "")
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err := goleHttp.New().URL(server.URL).Stream(context.Background())
+		var statusError *goleHttp.StatusError
+		True(t, errors.As(err, &statusError))
+	}
+
+	// 流式请求的失败同样熔断，普通请求和流式请求都不发出
+	_, err := goleHttp.New().URL(server.URL).Stream(context.Background())
+	var openError *goleHttp.CircuitOpenError
+	True(t, errors.As(err, &openError))
+	_, err = goleHttp.GetSimple(server.URL)
+	True(t, errors.As(err, &openError))
+	Equal(t, atomic.LoadInt32(count), int32(2))
+}
@@ -0,0 +1,175 @@
+package test
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goleHttp "github.com/isyscore/gole/http"
+)
+
+func newStreamServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/upload":
+			reader, _ := r.MultipartReader()
+			var result []string
+			for {
+				part, err := reader.NextPart()
+				if err != nil {
+					break
+				}
+				content, _ := ioutil.ReadAll(part)
+				sum := md5.Sum(content)
+				result = append(result, fmt.Sprintf("%v:%v:%v:%v", part.FormName(), part.FileName(), len(content), hex.EncodeToString(sum[:])))
+			}
+			_, _ = w.Write([]byte(strings.Join(result, ",")))
+		case "/raw":
+			content, _ := ioutil.ReadAll(r.Body)
+			_, _ = w.Write([]byte(fmt.Sprintf("%v", len(content))))
+		case "/ndjson":
+			flusher := w.(http.Flusher)
+			for i := 0; i < 3; i++ {
+				_, _ = w.Write([]byte(fmt.Sprintf("{\"index\":%v}\n", i)))
+				flusher.Flush()
+			}
+		case "/sse":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(": comment\nid: 1\nevent: add\ndata: line1\ndata: line2\n\ndata: {\"a\":1}\r\n\r\nretry: 100\nid: 3\ndata: last\n\ndata: incomplete"))
+		case "/file":
+			w.Header().Set("Content-Length", "10000")
+			_, _ = w.Write([]byte(strings.Repeat("a", 10000)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("not found"))
+		}
+	}))
+}
+
+func TestStreamUpload(t *testing.T) {
+	server := newStreamServer()
+	defer server.Close()
+
+	dir, _ := ioutil.TempDir("", "gole-stream")
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "big.txt")
+	content := strings.Repeat("0123456789", 200000)
+	_ = ioutil.WriteFile(filePath, []byte(content), 0644)
+	sum := md5.Sum([]byte(content))
+
+	response, err := goleHttp.New().Method("POST").URL(server.URL+"/upload").MultipartField("name", "gole").MultipartFilePath("file", filePath).MultipartFile("other", "b.txt", strings.NewReader("b")).Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, response.String(), "name::4:"+md5Hex("gole")+",file:big.txt:2000000:"+hex.EncodeToString(sum[:])+",other:b.txt:1:"+md5Hex("b"))
+
+	_, err = goleHttp.New().Method("POST").URL(server.URL+"/upload").MultipartFilePath("file", filepath.Join(dir, "none.txt")).Do(context.Background())
+	True(t, err != nil)
+
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		for i := 0; i < 100; i++ {
+			_, _ = pipeWriter.Write([]byte("0123456789"))
+		}
+		_ = pipeWriter.Close()
+	}()
+	response, _ = goleHttp.New().Method("PUT").URL(server.URL+"/raw").Body(pipeReader, "application/octet-stream").Do(context.Background())
+	Equal(t, response.String(), "1000")
+}
+
+func md5Hex(content string) string {
+	sum := md5.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestStreamReadLines(t *testing.T) {
+	server := newStreamServer()
+	defer server.Close()
+
+	stream, err := goleHttp.New().URL(server.URL + "/ndjson").Stream(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	var indexes []int
+	err = stream.ReadLines(func(line string) error {
+		value := map[string]int{}
+		if err := json.Unmarshal([]byte(line), &value); err != nil {
+			return err
+		}
+		indexes = append(indexes, value["index"])
+		return nil
+	})
+	True(t, err == nil)
+	Equal(t, fmt.Sprintf("%v", indexes), "[0 1 2]")
+
+	stream, _ = goleHttp.New().URL(server.URL + "/ndjson").Stream(context.Background())
+	stop := errors.New("stop")
+	err = stream.ReadLines(func(line string) error {
+		return stop
+	})
+	True(t, err == stop)
+
+	_, err = goleHttp.New().URL(server.URL + "/none").Stream(context.Background())
+	var statusError *goleHttp.StatusError
+	True(t, errors.As(err, &statusError))
+	Equal(t, string(statusError.Body), "not found")
+}
+
+func TestStreamReadEvents(t *testing.T) {
+	server := newStreamServer()
+	defer server.Close()
+
+	stream, err := goleHttp.New().URL(server.URL + "/sse").Stream(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	var events []goleHttp.Event
+	err = stream.ReadEvents(func(event *goleHttp.Event) error {
+		events = append(events, *event)
+		return nil
+	})
+	True(t, err == nil)
+	Equal(t, len(events), 3)
+	Equal(t, events[0].Id, "1", events[0].Event, "add", events[0].Data, "line1\nline2")
+	Equal(t, events[1].Id, "1", events[1].Event, "", events[1].Data, `{"a":1}`)
+	Equal(t, events[2].Id, "3", events[2].Retry, 100, events[2].Data, "last")
+}
+
+func TestDownload(t *testing.T) {
+	server := newStreamServer()
+	defer server.Close()
+
+	dir, _ := ioutil.TempDir("", "gole-download")
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "download.txt")
+
+	var lastWritten, lastTotal int64
+	written, err := goleHttp.New().URL(server.URL+"/file").Download(context.Background(), filePath, func(written, total int64) {
+		lastWritten, lastTotal = written, total
+	})
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	content, _ := ioutil.ReadFile(filePath)
+	Equal(t, written, int64(10000), lastWritten, int64(10000), lastTotal, int64(10000), len(content), 10000)
+
+	_, err = goleHttp.New().URL(server.URL+"/none").Download(context.Background(), filepath.Join(dir, "none.txt"), nil)
+	True(t, err != nil)
+	_, statErr := os.Stat(filepath.Join(dir, "none.txt"))
+	True(t, os.IsNotExist(statErr))
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	goleHttp "github.com/isyscore/gole/http"
+)
+
+func newHeaderEchoServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("X-Request-Id") + "|" + r.Header.Get("X-Order")))
+	}))
+}
+
+func TestInterceptorOrder(t *testing.T) {
+	server := newHeaderEchoServer()
+	defer server.Close()
+
+	var order []string
+	record := func(name string) goleHttp.Interceptor {
+		return func(req *http.Request, next goleHttp.Handler) (*http.Response, error) {
+			order = append(order, name+"-before")
+			req.Header.Set("X-Order", req.Header.Get("X-Order")+name)
+			rsp, err := next(req)
+			order = append(order, name+"-after")
+			return rsp, err
+		}
+	}
+
+	goleHttp.AddInterceptor(record("g"))
+	defer goleHttp.ClearInterceptors()
+
+	client := goleHttp.NewClient(nil, record("c1"))
+	client.AddInterceptor(record("c2"))
+	response, err := client.New().URL(server.URL).Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, response.String(), "||gc1c2")
+	Equal(t, strings.Join(order, ","), "g-before,c1-before,c2-before,c2-after,c1-after,g-after")
+
+	// 其他客户端只经过全局拦截器
+	data, _ := goleHttp.GetSimple(server.URL)
+	Equal(t, string(data), "||g")
+
+	// 拦截器可以直接返回
+	shortCircuit := goleHttp.NewClient(nil, func(req *http.Request, next goleHttp.Handler) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader("mock"))}, nil
+	})
+	response, _ = shortCircuit.New().URL(server.URL).Do(context.Background())
+	Equal(t, response.String(), "mock")
+}
+
+func TestBuiltinInterceptors(t *testing.T) {
+	server := newHeaderEchoServer()
+	defer server.Close()
+
+	counter := goleHttp.NewMetricsCounter()
+	client := goleHttp.NewClient(nil,
+		goleHttp.AuthInterceptor("Authorization", func(ctx context.Context) (string, error) {
+			return "Bearer token", nil
+		}),
+		goleHttp.PropagateHeaderInterceptor("X-Request-Id"),
+		goleHttp.LogInterceptor(nil),
+		goleHttp.MetricsInterceptor(counter.Record),
+	)
+
+	inbound := httptest.NewRequest("GET", "/inbound", nil)
+	inbound.Header.Set("X-Request-Id", "req-1")
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = inbound
+
+	response, err := client.New().URL(server.URL).Do(goleHttp.WithGinContext(c))
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, response.String(), "Bearer token|req-1|")
+
+	// gin.Context本身作为ctx
+	response, _ = client.New().URL(server.URL).Do(c)
+	Equal(t, response.String(), "Bearer token|req-1|")
+
+	failed := goleHttp.NewClient(nil, goleHttp.AuthInterceptor("Authorization", func(ctx context.Context) (string, error) {
+		return "", errors.New("no token")
+	}))
+	_, err = failed.New().URL(server.URL).Do(context.Background())
+	True(t, err != nil)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	Equal(t, counter.Counters()[host].Requests, int64(2), counter.Counters()[host].Failures, int64(0))
+}
@@ -0,0 +1,284 @@
+package test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/isyscore/gole/config"
+	goleHttp "github.com/isyscore/gole/http"
+)
+
+// 返回实例名的本地服务，status不为200时返回该状态码
+func newInstanceServers(statusList ...int) ([]*httptest.Server, []*int32) {
+	var servers []*httptest.Server
+	var counts []*int32
+	for index, status := range statusList {
+		name, code, count := "instance"+strconv.Itoa(index), status, new(int32)
+		servers = append(servers, httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(count, 1)
+			w.WriteHeader(code)
+			_, _ = w.Write([]byte(name))
+		})))
+		counts = append(counts, count)
+	}
+	return servers, counts
+}
+
+func addressesOf(servers []*httptest.Server) []string {
+	var addresses []string
+	for _, server := range servers {
+		addresses = append(addresses, server.Listener.Addr().String())
+	}
+	return addresses
+}
+
+func closeServers(servers []*httptest.Server) {
+	for _, server := range servers {
+		server.Close()
+	}
+}
+
+func TestLoadBalanceRoundRobin(t *testing.T) {
+	servers, counts := newInstanceServers(200, 200, 200)
+	defer closeServers(servers)
+	goleHttp.SetResolver(goleHttp.StaticResolver{"order-service": addressesOf(servers)})
+	defer goleHttp.SetResolver(&goleHttp.ConfigResolver{})
+
+	for i := 0; i < 6; i++ {
+		data, err := goleHttp.GetSimple("lb://order-service/api/order")
+		if err != nil {
+			Err(t, err)
+			return
+		}
+		True(t, strings.HasPrefix(string(data), "instance"))
+	}
+	Equal(t, atomic.LoadInt32(counts[0]), int32(2), atomic.LoadInt32(counts[1]), int32(2), atomic.LoadInt32(counts[2]), int32(2))
+
+	// 没有实例
+	_, err := goleHttp.GetSimple("lb://unknown-service/api")
+	var discoveryError *goleHttp.DiscoveryError
+	True(t, errors.As(err, &discoveryError))
+	Equal(t, discoveryError.ServiceName, "unknown-service")
+}
+
+func TestLoadBalanceRandom(t *testing.T) {
+	servers, counts := newInstanceServers(200, 200)
+	defer closeServers(servers)
+	goleHttp.SetResolver(goleHttp.StaticResolver{"order-service": addressesOf(servers)})
+	goleHttp.SetBalancer(goleHttp.BalancerOf("random"))
+	defer goleHttp.SetResolver(&goleHttp.ConfigResolver{})
+	defer goleHttp.SetBalancer(&goleHttp.RoundRobinBalancer{})
+
+	for i := 0; i < 40; i++ {
+		if _, err := goleHttp.GetSimple("lb://order-service/api/order"); err != nil {
+			Err(t, err)
+			return
+		}
+	}
+	Equal(t, atomic.LoadInt32(counts[0])+atomic.LoadInt32(counts[1]), int32(40))
+	True(t, atomic.LoadInt32(counts[0]) > 0)
+	True(t, atomic.LoadInt32(counts[1]) > 0)
+}
+
+func TestLoadBalanceLeastPending(t *testing.T) {
+	balancer := &goleHttp.LeastPendingBalancer{}
+	instances := []*goleHttp.Instance{{Address: "a", Pending: 2}, {Address: "b", Pending: 0}, {Address: "c", Pending: 1}}
+	Equal(t, balancer.Pick("order-service", instances).Address, "b")
+
+	// 慢实例上有进行中的请求时，新的请求都发到其他实例
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte("slow"))
+	}))
+	defer slow.Close()
+	defer close(release)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("fast"))
+	}))
+	defer fast.Close()
+
+	goleHttp.SetResolver(goleHttp.StaticResolver{"order-service": {slow.Listener.Addr().String()}})
+	goleHttp.SetBalancer(goleHttp.BalancerOf("leastPending"))
+	defer goleHttp.SetResolver(&goleHttp.ConfigResolver{})
+	defer goleHttp.SetBalancer(&goleHttp.RoundRobinBalancer{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_, _ = goleHttp.GetCtx(ctx, "lb://order-service/api", nil, nil)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	goleHttp.SetResolver(goleHttp.StaticResolver{"order-service": {slow.Listener.Addr().String(), fast.Listener.Addr().String()}})
+	for i := 0; i < 5; i++ {
+		data, err := goleHttp.GetSimple("lb://order-service/api")
+		if err != nil {
+			Err(t, err)
+			return
+		}
+		Equal(t, string(data), "fast")
+	}
+}
+
+func TestOutlierEjection(t *testing.T) {
+	servers, counts := newInstanceServers(500, 200)
+	defer closeServers(servers)
+	goleHttp.SetResolver(goleHttp.StaticResolver{"order-service": addressesOf(servers)})
+	goleHttp.SetOutlierPolicy(&goleHttp.OutlierPolicy{ConsecutiveFailures: 2, EjectionTime: time.Minute, MaxEjectionPercent: 50})
+	defer goleHttp.SetResolver(&goleHttp.ConfigResolver{})
+	defer goleHttp.SetOutlierPolicy(goleHttp.DefaultOutlierPolicy())
+
+	for i := 0; i < 10; i++ {
+		_, _ = goleHttp.GetSimple("lb://order-service/api")
+	}
+	// 连续失败2次后摘除，之后都发到正常的实例
+	Equal(t, atomic.LoadInt32(counts[0]), int32(2), atomic.LoadInt32(counts[1]), int32(8))
+
+	// 超过最多摘除的比例时不再摘除
+	closeServers(servers[1:])
+	servers2, counts2 := newInstanceServers(500, 500)
+	defer closeServers(servers2)
+	goleHttp.SetResolver(goleHttp.StaticResolver{"order-service": addressesOf(servers2)})
+	goleHttp.SetOutlierPolicy(&goleHttp.OutlierPolicy{ConsecutiveFailures: 1, EjectionTime: time.Minute, MaxEjectionPercent: 50})
+	for i := 0; i < 4; i++ {
+		_, _ = goleHttp.GetSimple("lb://order-service/api")
+	}
+	Equal(t, atomic.LoadInt32(counts2[0])+atomic.LoadInt32(counts2[1]), int32(4))
+	True(t, atomic.LoadInt32(counts2[0]) > 0)
+	True(t, atomic.LoadInt32(counts2[1]) > 0)
+}
+
+// 服务发现不再返回的实例不参与最多摘除比例的计算
+func TestOutlierRemovedInstance(t *testing.T) {
+	servers, counts := newInstanceServers(500, 500, 200, 200)
+	defer closeServers(servers)
+	goleHttp.SetOutlierPolicy(&goleHttp.OutlierPolicy{ConsecutiveFailures: 2, EjectionTime: time.Minute, MaxEjectionPercent: 50})
+	defer goleHttp.SetOutlierPolicy(goleHttp.DefaultOutlierPolicy())
+	defer goleHttp.SetResolver(&goleHttp.ConfigResolver{})
+
+	goleHttp.SetResolver(goleHttp.StaticResolver{"removed-service": addressesOf(servers)})
+	for i := 0; i < 4; i++ {
+		_, _ = goleHttp.GetSimple("lb://removed-service/api")
+	}
+
+	goleHttp.SetResolver(goleHttp.StaticResolver{"removed-service": addressesOf(servers[:2])})
+	for i := 0; i < 6; i++ {
+		_, _ = goleHttp.GetSimple("lb://removed-service/api")
+	}
+	Equal(t, atomic.LoadInt32(counts[0]), int32(2), atomic.LoadInt32(counts[1]), int32(6))
+}
+
+func TestConfigResolver(t *testing.T) {
+	servers, counts := newInstanceServers(200, 200)
+	defer closeServers(servers)
+	config.SetValue("base.http.services.user-service", strings.Join(addressesOf(servers), ","))
+
+	addresses, err := (&goleHttp.ConfigResolver{}).Resolve(context.Background(), "user-service")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, strings.Join(addresses, ","), strings.Join(addressesOf(servers), ","))
+
+	for i := 0; i < 4; i++ {
+		if _, err := goleHttp.GetSimple("lb://user-service/api"); err != nil {
+			Err(t, err)
+			return
+		}
+	}
+	Equal(t, atomic.LoadInt32(counts[0]), int32(2), atomic.LoadInt32(counts[1]), int32(2))
+}
+
+func TestDnsSrvResolver(t *testing.T) {
+	servers, counts := newInstanceServers(200, 200)
+	defer closeServers(servers)
+	dnsServer, err := newSrvServer(addressesOf(servers))
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	defer dnsServer.Close()
+
+	srvResolver := &goleHttp.DnsSrvResolver{Service: "http", Proto: "tcp", Resolver: &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return net.Dial("udp", dnsServer.LocalAddr().String())
+		},
+	}}
+	addresses, err := srvResolver.Resolve(context.Background(), "pay-service.local")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, len(addresses), 2)
+	True(t, strings.HasPrefix(addresses[0], "localhost:"))
+
+	goleHttp.SetResolver(srvResolver)
+	defer goleHttp.SetResolver(&goleHttp.ConfigResolver{})
+	for i := 0; i < 4; i++ {
+		if _, err := goleHttp.GetSimple("lb://pay-service.local/api"); err != nil {
+			Err(t, err)
+			return
+		}
+	}
+	Equal(t, atomic.LoadInt32(counts[0]), int32(2), atomic.LoadInt32(counts[1]), int32(2))
+}
+
+// 只应答SRV查询的dns服务，target为localhost，端口为实例的端口；优先级不同，保证返回的顺序固定
+func newSrvServer(addresses []string) (net.PacketConn, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, remote, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query := buf[:n]
+			// 跳过header和问题中的域名，然后是type和class
+			end := 12
+			for end < n && query[end] != 0 {
+				end += int(query[end]) + 1
+			}
+			end += 5
+			if end > n {
+				continue
+			}
+
+			answer := append([]byte{}, query[:2]...)
+			answer = append(answer, 0x81, 0x80, 0, 1, 0, byte(len(addresses)), 0, 0, 0, 0)
+			answer = append(answer, query[12:end]...)
+			for index, address := range addresses {
+				_, port, _ := net.SplitHostPort(address)
+				portValue, _ := strconv.Atoi(port)
+				target := append([]byte{9}, "localhost"...)
+				target = append(target, 0)
+
+				rdata := make([]byte, 6)
+				binary.BigEndian.PutUint16(rdata[0:], uint16(index+1))
+				binary.BigEndian.PutUint16(rdata[2:], 10)
+				binary.BigEndian.PutUint16(rdata[4:], uint16(portValue))
+				rdata = append(rdata, target...)
+
+				// 名字指向问题中的域名，type=SRV，class=IN，ttl=60
+				answer = append(answer, 0xc0, 12, 0, 33, 0, 1, 0, 0, 0, 60, byte(len(rdata)>>8), byte(len(rdata)))
+				answer = append(answer, rdata...)
+			}
+			_, _ = conn.WriteTo(answer, remote)
+		}
+	}()
+	return conn, nil
+}
@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/isyscore/gole/config"
+	goleHttp "github.com/isyscore/gole/http"
+	"github.com/isyscore/gole/util"
+	"github.com/isyscore/gole/web"
+)
+
+type PageItem struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func pageItems(count int) []PageItem {
+	var items []PageItem
+	for i := 1; i <= count; i++ {
+		items = append(items, PageItem{Id: i, Name: "item" + util.ToString(i)})
+	}
+	return items
+}
+
+func pageEngine(items []PageItem) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/items", func(c *gin.Context) {
+		req, err := web.PageRequestOf(c)
+		if err != nil {
+			web.FailedOfError(c, err)
+			return
+		}
+		var result []PageItem
+		if req.Offset() < len(items) {
+			end := req.Offset() + req.Limit()
+			if end > len(items) {
+				end = len(items)
+			}
+			result = items[req.Offset():end]
+		}
+		web.SuccessOfPage(c, result, int64(len(items)), req)
+	})
+	engine.GET("/cursor", func(c *gin.Context) {
+		req, err := web.CursorRequestOf(c)
+		if err != nil {
+			web.FailedOfError(c, err)
+			return
+		}
+		lastId := 0
+		if req.Cursor != "" {
+			if err := web.DecodeCursor(req.Cursor, &lastId); err != nil {
+				web.FailedOfError(c, err)
+				return
+			}
+		}
+		end := lastId + req.Size
+		if end > len(items) {
+			end = len(items)
+		}
+		nextCursor := ""
+		if end < len(items) {
+			nextCursor, _ = web.EncodeCursor(end)
+		}
+		web.SuccessOfCursor(c, items[lastId:end], nextCursor)
+	})
+	engine.GET("/sort", func(c *gin.Context) {
+		req, err := web.PageRequestOf(c)
+		if err != nil {
+			web.FailedOfError(c, err)
+			return
+		}
+		web.SuccessOfStandard(c, req)
+	})
+	return engine
+}
+
+func servePage(engine *gin.Engine, path string) (int, map[string]interface{}) {
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	result := map[string]interface{}{}
+	_ = json.Unmarshal(recorder.Body.Bytes(), &result)
+	return recorder.Code, result
+}
+
+func TestPageRequest(t *testing.T) {
+	engine := pageEngine(pageItems(45))
+
+	// 默认第1页，每页20条
+	_, result := servePage(engine, "/items")
+	data := result["data"].(map[string]interface{})
+	Equal(t, data["page"], float64(1), data["size"], float64(20), data["total"], float64(45), data["pages"], float64(3))
+	Equal(t, len(data["items"].([]interface{})), 20)
+
+	// 超过总数的页返回空集合
+	_, result = servePage(engine, "/items?page=9&size=10")
+	data = result["data"].(map[string]interface{})
+	Equal(t, data["pages"], float64(5), util.ObjectToJson(data["items"]), "[]")
+
+	// 超过最大值按照最大值
+	config.SetValue("base.server.page.maxSize", "15")
+	defer config.SetValue("base.server.page.maxSize", "1000")
+	_, result = servePage(engine, "/items?size=100")
+	Equal(t, result["data"].(map[string]interface{})["size"], float64(15))
+
+	_, result = servePage(engine, "/sort?sort=name,-createTime&sort=age:desc&sort=user.id:asc")
+	sortOrders := result["data"].(map[string]interface{})["Sort"].([]interface{})
+	Equal(t, len(sortOrders), 4)
+	Equal(t, util.ObjectToJson(sortOrders[1]), `{"desc":true,"field":"createTime"}`, util.ObjectToJson(sortOrders[3]), `{"desc":false,"field":"user.id"}`)
+
+	status, result := servePage(engine, "/sort?page=0&size=a&sort=name%20drop")
+	Equal(t, status, http.StatusBadRequest)
+	fields := result["data"].(map[string]interface{})
+	Equal(t, fields["page"], "必须为大于0的整数", fields["size"], "必须为大于0的整数", fields["sort"], "排序格式不正确：name drop")
+
+	status, result = servePage(engine, "/cursor?cursor=%25%25")
+	Equal(t, status, http.StatusBadRequest, result["data"].(map[string]interface{})["cursor"], "游标格式不正确")
+}
+
+func TestGetAllPages(t *testing.T) {
+	server := httptest.NewServer(pageEngine(pageItems(45)))
+	defer server.Close()
+
+	var items []PageItem
+	if err := goleHttp.GetAllPagesInto(context.Background(), server.URL+"/items", nil, nil, 10, &items); err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, len(items), 45, items[0].Id, 1, items[44].Name, "item45")
+
+	items = nil
+	if err := goleHttp.GetAllPagesInto(context.Background(), server.URL+"/cursor", nil, nil, 7, &items); err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, len(items), 45, items[44].Id, 45)
+
+	// 整除的时候不多请求
+	requests := 0
+	emptyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		pageEngine(pageItems(20)).ServeHTTP(w, r)
+	}))
+	defer emptyServer.Close()
+	pages := 0
+	err := goleHttp.GetAllPages(context.Background(), emptyServer.URL+"/items", nil, nil, 10, func(items json.RawMessage) error {
+		pages++
+		return nil
+	})
+	True(t, err == nil)
+	Equal(t, pages, 2, requests, 2)
+
+	// 没有返回total时取到不满一页为止
+	noTotalServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		var pageData []PageItem
+		for id := (page-1)*size + 1; id <= page*size && id <= 25; id++ {
+			pageData = append(pageData, PageItem{Id: id, Name: "item" + strconv.Itoa(id)})
+		}
+		_, _ = w.Write([]byte(util.ObjectToJson(map[string]interface{}{"code": "success", "data": map[string]interface{}{"items": pageData, "page": page, "size": size}})))
+	}))
+	defer noTotalServer.Close()
+	items = nil
+	if err := goleHttp.GetAllPagesInto(context.Background(), noTotalServer.URL, nil, nil, 10, &items); err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, len(items), 25, items[24].Id, 25)
+
+	// 请求失败返回错误
+	err = goleHttp.GetAllPages(context.Background(), server.URL+"/items", nil, map[string]string{"sort": "a b"}, 10, func(items json.RawMessage) error {
+		return nil
+	})
+	statusError, ok := err.(*goleHttp.StatusError)
+	True(t, ok)
+	Equal(t, statusError.StatusCode, http.StatusBadRequest)
+}
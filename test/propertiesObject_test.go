@@ -0,0 +1,167 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/isyscore/gole/yaml"
+)
+
+type PropBase struct {
+	Id      int64  `json:"id"`
+	Creator string `yaml:"creator,omitempty"`
+}
+
+type PropServer struct {
+	Host string `prop:"host" yaml:"yamlHost" json:"jsonHost"`
+	Port int    `yaml:"port"`
+}
+
+type PropSetting struct {
+	PropBase
+	Name      string            `json:"name"`
+	Enable    bool              `prop:"enable"`
+	Timeout   time.Duration     `yaml:"timeout"`
+	StartTime time.Time         `yaml:"startTime"`
+	Servers   []PropServer      `yaml:"servers"`
+	Tags      []string          `yaml:"tags"`
+	Labels    map[string]string `yaml:"labels"`
+	Remark    string            `json:"remark,omitempty"`
+	Ignore    string            `prop:"-"`
+	Parent    *PropServer       `yaml:"parent"`
+	Ratio     float64
+	private   string
+}
+
+func TestObjectToProperties(t *testing.T) {
+	startTime := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	setting := PropSetting{
+		PropBase:  PropBase{Id: 12},
+		Name:      "demo",
+		Enable:    true,
+		Timeout:   90 * time.Second,
+		StartTime: startTime,
+		Servers:   []PropServer{{Host: "a", Port: 80}, {Host: "b", Port: 81}},
+		Tags:      []string{"x1", "x2"},
+		Labels:    map[string]string{"zone": "hz", "app": "gole"},
+		Ignore:    "ignore",
+		Ratio:     0.5,
+		private:   "private",
+	}
+
+	act, err := yaml.ObjectToProperties(setting)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+
+	expect := "id=12\n" +
+		"name=demo\n" +
+		"enable=true\n" +
+		"timeout=1m30s\n" +
+		"startTime=2022-01-02T03:04:05Z\n" +
+		"servers[0].host=a\n" +
+		"servers[0].port=80\n" +
+		"servers[1].host=b\n" +
+		"servers[1].port=81\n" +
+		"tags[0]=x1\n" +
+		"tags[1]=x2\n" +
+		"labels.app=gole\n" +
+		"labels.zone=hz\n" +
+		"ratio=0.5\n"
+	Equal(t, act, expect)
+}
+
+func TestPropertiesToObject(t *testing.T) {
+	content := "id=12\n" +
+		"creator=admin\n" +
+		"name=demo\n" +
+		"enable=true\n" +
+		"timeout=1m30s\n" +
+		"startTime=2022-01-02 03:04:05\n" +
+		"servers[1].host=b\n" +
+		"servers[1].port=81\n" +
+		"servers[0].host=a\n" +
+		"servers[0].port=80\n" +
+		"tags=x1, x2\n" +
+		"labels.zone=hz\n" +
+		"parent.host=p\n" +
+		"ignore=ignore\n" +
+		"Ratio=0.5\n"
+
+	setting := PropSetting{}
+	err := yaml.PropertiesToObject(content, &setting)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+
+	Equal(t, setting.Id, int64(12), setting.Creator, "admin", setting.Name, "demo", setting.Enable, true)
+	Equal(t, setting.Timeout, 90*time.Second)
+	Equal(t, setting.StartTime.Equal(time.Date(2022, 1, 2, 3, 4, 5, 0, time.Local)), true)
+	Equal(t, len(setting.Servers), 2)
+	Equal(t, setting.Servers[0].Host, "a", setting.Servers[1].Port, 81)
+	Equal(t, len(setting.Tags), 2)
+	Equal(t, setting.Tags[1], "x2")
+	Equal(t, setting.Labels["zone"], "hz")
+	Equal(t, setting.Parent.Host, "p")
+	Equal(t, setting.Ignore, "")
+	Equal(t, setting.Ratio, 0.5)
+
+	err = yaml.PropertiesToObject("enable=yes-no", &setting)
+	True(t, err != nil)
+	err = yaml.PropertiesToObject("timeout=abc", &setting)
+	True(t, err != nil)
+	err = yaml.PropertiesToObject("a=b", setting)
+	True(t, err != nil)
+}
+
+func TestObjectPropertiesRoundTrip(t *testing.T) {
+	setting := PropSetting{
+		PropBase:  PropBase{Id: 7, Creator: "c"},
+		Name:      "line1\nline2",
+		Timeout:   1500 * time.Millisecond,
+		StartTime: time.Date(2022, 1, 2, 3, 4, 5, 600, time.UTC),
+		Servers:   []PropServer{{Host: "h:1", Port: 1}},
+		Labels:    map[string]string{"k": " v"},
+		Remark:    "remark",
+		Parent:    &PropServer{Host: "p"},
+	}
+
+	content, err := yaml.ObjectToProperties(&setting)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+
+	act := PropSetting{}
+	if err := yaml.PropertiesToObject(content, &act); err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, act.Id, setting.Id, act.Creator, setting.Creator, act.Name, setting.Name, act.Timeout, setting.Timeout)
+	Equal(t, act.StartTime.Equal(setting.StartTime), true)
+	Equal(t, act.Servers[0].Host, "h:1", act.Labels["k"], " v", act.Remark, "remark", act.Parent.Host, "p")
+}
+
+type PropBytes struct {
+	Code  [4]byte
+	Bytes []byte
+}
+
+func TestObjectToPropertiesBytes(t *testing.T) {
+	// 按值传入的[N]byte不可寻址
+	content, err := yaml.ObjectToProperties(PropBytes{Code: [4]byte{'a', 'b', 'c', 'd'}, Bytes: []byte("xyz")})
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, content, "code=abcd\nbytes=xyz\n")
+
+	act := PropBytes{}
+	if err := yaml.PropertiesToObject(content, &act); err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, string(act.Code[:]), "abcd", string(act.Bytes), "xyz")
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/isyscore/gole/yaml"
+	"github.com/magiconair/properties"
+)
+
+// java.util.Properties格式的兼容性测试
+func TestPropertiesJavaFormat(t *testing.T) {
+	bytes, err := ioutil.ReadFile("./resources/properties/java.properties")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+
+	actMap, err := yaml.PropertiesToMap(string(bytes))
+	if err != nil {
+		Err(t, err)
+		return
+	}
+
+	expectMap := map[string]string{
+		"simple":              "value",
+		"colon":               "value",
+		"space":               "value",
+		"spaces":              "value with spaces  ",
+		"tab":                 "value",
+		"empty":               "",
+		"keyOnly":             "",
+		"escaped=key":         "v1",
+		"escaped:key":         "v2",
+		"escaped key":         "v3",
+		"escaped#key":         "v4",
+		"equalInValue":        "a=b:c",
+		"unicode":             "中文",
+		"emoji":               "😀",
+		"utf8":                "中文",
+		"specials":            "tab\there\nnewline\rreturn\fformfeed",
+		"backslash":           "c:\\path\\to",
+		"unknownEscape":       "qwe",
+		"multi":               "first, second, third",
+		"notComment":          "# not comment",
+		"continuationComment": "a# b",
+		"evenBackslash":       "end\\",
+		"nextKey":             "after",
+		"leadingBlank":        "  two",
+		"indentedKey":         "indented",
+	}
+
+	Equal(t, len(actMap), len(expectMap))
+	for key, expect := range expectMap {
+		act, exist := actMap[key]
+		TrueErr(t, exist, "key not exist: "+key)
+		Equal(t, act, expect)
+	}
+
+	// 与第三方的java兼容实现对比，其不支持代理对的\uXXXX，这里跳过
+	pro := properties.NewProperties()
+	pro.DisableExpansion = true
+	if err := pro.Load(bytes, properties.UTF8); err != nil {
+		Err(t, err)
+		return
+	}
+	for key, expect := range pro.Map() {
+		if key == "emoji" {
+			continue
+		}
+		Equal(t, actMap[key], expect)
+	}
+}
+
+func TestPropertiesLineEnding(t *testing.T) {
+	actMap, err := yaml.PropertiesToMap("a=1\r\nb=2\\\r\n   3\rc=4")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, actMap["a"], "1", actMap["b"], "23", actMap["c"], "4")
+}
+
+func TestPropertiesMalformedUnicode(t *testing.T) {
+	_, err := yaml.PropertiesToMap("a=\\u12")
+	True(t, err != nil)
+
+	_, err = yaml.PropertiesToMap("a=\\uzzzz")
+	True(t, err != nil)
+}
+
+func TestPropertiesItemLineList(t *testing.T) {
+	lines := yaml.GetPropertiesItemLineList("# comment\na=1\\\n  2\n\nb=3")
+	Equal(t, len(lines), 2)
+	Equal(t, lines[0], "a=12", lines[1], "b=3")
+}
+
+func TestMapToPropertiesEscape(t *testing.T) {
+	dataMap := map[string]interface{}{
+		"key with=special:chars#": " leading and trailing ",
+		"multi":                   "line1\nline2\n",
+		"path":                    "c:\\path",
+		"unicode":                 "中文",
+		"emoji":                   "a😀",
+		"control":                 "a\u0001b",
+		"nil":                     nil,
+	}
+
+	content, err := yaml.MapToProperties(dataMap)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	True(t, strings.Contains(content, "key\\ with\\=special\\:chars\\#=\\ leading and trailing \n"))
+	True(t, strings.Contains(content, "multi=line1\\nline2\\n\n"))
+	True(t, strings.Contains(content, "path=c:\\\\path\n"))
+	True(t, strings.Contains(content, "unicode=\\u4E2D\\u6587\n"))
+	True(t, strings.Contains(content, "emoji=a\\uD83D\\uDE00\n"))
+	True(t, strings.Contains(content, "control=a\\u0001b\n"))
+	True(t, strings.Contains(content, "nil=\n"))
+
+	// 写出后再读取，内容保持一致
+	actMap, err := yaml.PropertiesToMap(content)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	for key, value := range dataMap {
+		if value == nil {
+			Equal(t, actMap[key], "")
+			continue
+		}
+		Equal(t, actMap[key], value)
+	}
+
+	// 第三方的实现不支持代理对，这里跳过
+	pro := properties.NewProperties()
+	pro.DisableExpansion = true
+	if err := pro.Load([]byte(content), properties.UTF8); err != nil {
+		Err(t, err)
+		return
+	}
+	for key, expect := range pro.Map() {
+		if key == "emoji" {
+			continue
+		}
+		Equal(t, actMap[key], expect)
+	}
+}
+
+func TestPropertiesMultiLineToYaml(t *testing.T) {
+	bytes, err := ioutil.ReadFile("./resources/yml/multi_line.yml")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+
+	content, err := yaml.YamlToProperties(string(bytes))
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	yamlContent, err := yaml.PropertiesToYaml(content)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+
+	expectMap, _ := yaml.YamlToMap(string(bytes))
+	actMap, err := yaml.YamlToMap(yamlContent)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	expectYaml, _ := yaml.ObjectToYaml(expectMap)
+	actYaml, _ := yaml.ObjectToYaml(actMap)
+	Equal(t, actYaml, expectYaml)
+}
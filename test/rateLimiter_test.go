@@ -0,0 +1,191 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/isyscore/gole/config"
+	goleHttp "github.com/isyscore/gole/http"
+)
+
+// 记录最大并发数的服务，每个请求处理delay
+func newConcurrencyServer(delay time.Duration) (*httptest.Server, *int32) {
+	var current, max int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := atomic.AddInt32(&current, 1)
+		for {
+			old := atomic.LoadInt32(&max)
+			if value <= old || atomic.CompareAndSwapInt32(&max, old, value) {
+				break
+			}
+		}
+		time.Sleep(delay)
+		atomic.AddInt32(&current, -1)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	return server, &max
+}
+
+func hostOf(server *httptest.Server) string {
+	return server.Listener.Addr().String()
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	server, max := newConcurrencyServer(50 * time.Millisecond)
+	defer server.Close()
+	goleHttp.SetHostLimit(hostOf(server), &goleHttp.LimitPolicy{MaxInFlight: 2})
+	defer goleHttp.SetHostLimit(hostOf(server), nil)
+
+	var wg sync.WaitGroup
+	var failed int32
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := goleHttp.GetSimple(server.URL); err != nil {
+				atomic.AddInt32(&failed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	Equal(t, atomic.LoadInt32(&failed), int32(0), atomic.LoadInt32(max), int32(2))
+
+	// 等待时ctx结束
+	goleHttp.SetHostLimit(hostOf(server), &goleHttp.LimitPolicy{MaxInFlight: 1})
+	go func() {
+		_, _ = goleHttp.GetSimple(server.URL)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := goleHttp.GetCtx(ctx, server.URL, nil, nil)
+	True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestConcurrencyLimitFailFast(t *testing.T) {
+	server, _ := newConcurrencyServer(100 * time.Millisecond)
+	defer server.Close()
+	goleHttp.SetHostLimit(hostOf(server), &goleHttp.LimitPolicy{MaxInFlight: 1, FailFast: true})
+	defer goleHttp.SetHostLimit(hostOf(server), nil)
+
+	go func() {
+		_, _ = goleHttp.GetSimple(server.URL)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := goleHttp.GetSimple(server.URL)
+	var limitError *goleHttp.LimitError
+	True(t, errors.As(err, &limitError))
+	Equal(t, limitError.Key, hostOf(server), limitError.Reason, "concurrency")
+
+	time.Sleep(150 * time.Millisecond)
+	_, err = goleHttp.GetSimple(server.URL)
+	True(t, err == nil)
+}
+
+func TestRateLimit(t *testing.T) {
+	server, _ := newConcurrencyServer(0)
+	defer server.Close()
+
+	// 突发1个，之后每秒20个
+	goleHttp.SetHostLimit(hostOf(server), &goleHttp.LimitPolicy{Rate: 20, Burst: 1})
+	defer goleHttp.SetHostLimit(hostOf(server), nil)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := goleHttp.GetSimple(server.URL); err != nil {
+			Err(t, err)
+			return
+		}
+	}
+	True(t, time.Since(start) >= 180*time.Millisecond)
+
+	// 重新设置的限流从满的令牌桶开始
+	goleHttp.SetHostLimit(hostOf(server), nil)
+	goleHttp.SetHostLimit(hostOf(server), &goleHttp.LimitPolicy{Rate: 1, Burst: 2, FailFast: true})
+	_, err1 := goleHttp.GetSimple(server.URL)
+	_, err2 := goleHttp.GetSimple(server.URL)
+	_, err3 := goleHttp.GetSimple(server.URL)
+	True(t, err1 == nil)
+	True(t, err2 == nil)
+	var limitError *goleHttp.LimitError
+	True(t, errors.As(err3, &limitError))
+	Equal(t, limitError.Reason, "rate")
+}
+
+func TestClientLimit(t *testing.T) {
+	server, _ := newConcurrencyServer(0)
+	defer server.Close()
+	goleHttp.RegisterClient("limited", goleHttp.NewClient(nil))
+	goleHttp.SetClientLimit("limited", &goleHttp.LimitPolicy{Rate: 1, Burst: 1, FailFast: true})
+	defer goleHttp.SetClientLimit("limited", nil)
+
+	client := goleHttp.Client("limited")
+	_, err := client.New().URL(server.URL).Do(context.Background())
+	True(t, err == nil)
+	_, err = client.New().URL(server.URL).Do(context.Background())
+	var limitError *goleHttp.LimitError
+	True(t, errors.As(err, &limitError))
+	Equal(t, limitError.Key, "client:limited")
+
+	// 其他客户端不受影响
+	_, err = goleHttp.GetSimple(server.URL)
+	True(t, err == nil)
+}
+
+func TestLimitConfigChange(t *testing.T) {
+	server, _ := newConcurrencyServer(0)
+	defer server.Close()
+
+	config.AppendValue("base.http.limits[0].host=" + hostOf(server) + "\nbase.http.limits[0].rate=1\nbase.http.limits[0].burst=1\nbase.http.limits[0].failFast=true")
+	_, err := goleHttp.GetSimple(server.URL)
+	True(t, err == nil)
+	_, err = goleHttp.GetSimple(server.URL)
+	var limitError *goleHttp.LimitError
+	True(t, errors.As(err, &limitError))
+
+	// 修改限流的配置
+	config.SetValue("base.http.limits[0].burst", "3")
+	config.SetValue("base.http.limits[0].rate", "1000")
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		_, err = goleHttp.GetSimple(server.URL)
+		True(t, err == nil)
+	}
+
+	// 不再配置该host时取消限流
+	config.SetValue("base.http.limits[0].rate", "0.001")
+	config.SetValue("base.http.limits[0].host", "127.0.0.1:1")
+	for i := 0; i < 5; i++ {
+		_, err = goleHttp.GetSimple(server.URL)
+		True(t, err == nil)
+	}
+	config.SetValue("base.http.limits[0].rate", "0")
+}
+
+// 限流拒绝的multipart请求不会留下写入请求体的协程
+func TestLimitMultipart(t *testing.T) {
+	server, _ := newConcurrencyServer(0)
+	defer server.Close()
+	goleHttp.SetHostLimit(hostOf(server), &goleHttp.LimitPolicy{Rate: 0.001, Burst: 1, FailFast: true})
+	defer goleHttp.SetHostLimit(hostOf(server), nil)
+
+	_, err := goleHttp.GetSimple(server.URL)
+	True(t, err == nil)
+
+	goroutines := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		_, err = goleHttp.New().Method(http.MethodPost).URL(server.URL).MultipartField("name", "gole").MultipartFile("file", "a.txt", strings.NewReader("content")).Do(context.Background())
+		var limitError *goleHttp.LimitError
+		True(t, errors.As(err, &limitError))
+	}
+	time.Sleep(20 * time.Millisecond)
+	True(t, runtime.NumGoroutine() < goroutines+5)
+}
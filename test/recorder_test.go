@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	goleHttp "github.com/isyscore/gole/http"
+	"github.com/isyscore/gole/http/httptest"
+)
+
+func TestRecorder(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gole-cassette")
+	defer os.RemoveAll(dir)
+	cassettePath := filepath.Join(dir, "cassettes", "users.json")
+
+	server := httptest.NewServer()
+	server.On("GET", "/users/{id}").ReplySuccess(map[string]string{"name": "gole"})
+	server.On("POST", "/users").Reply(200, "created")
+
+	// 录制
+	recorder, err := httptest.NewRecorder(cassettePath, httptest.ModeAuto)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, recorder.Mode(), httptest.ModeRecord)
+	client := goleHttp.NewClient(nil, recorder.Interceptor())
+
+	response, err := client.New().URL(server.URL+"/users/1").Header("Authorization", "secret").Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, response.String(), `{"code":"success","data":{"name":"gole"},"message":"成功"}`)
+	_, _ = client.New().Method("POST").URL(server.URL + "/users").JSON(map[string]string{"name": "a"}).Do(context.Background())
+	True(t, recorder.Save() == nil)
+	server.Close()
+
+	content, _ := ioutil.ReadFile(cassettePath)
+	True(t, strings.Contains(string(content), `"***"`))
+	True(t, !strings.Contains(string(content), "secret"))
+
+	// 回放，服务已经关闭
+	replayer, err := httptest.NewRecorder(cassettePath, httptest.ModeAuto)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, replayer.Mode(), httptest.ModeReplay, len(replayer.Interactions()), 2)
+	client = goleHttp.NewClient(nil, replayer.Interceptor())
+
+	user := standardUser{}
+	_, err = client.New().URL(server.URL + "/users/1").IntoStandard(&user).Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, user.Name, "gole")
+
+	response, _ = client.New().Method("POST").URL(server.URL + "/users").JSON(map[string]string{"name": "a"}).Do(context.Background())
+	Equal(t, response.String(), "created")
+
+	// 请求体不同则不匹配
+	_, err = client.New().Method("POST").URL(server.URL + "/users").JSON(map[string]string{"name": "b"}).Do(context.Background())
+	var recordError *httptest.RecordError
+	True(t, errors.As(err, &recordError))
+
+	// 忽略host
+	replayer.Matcher = httptest.IgnoreHostMatcher
+	_, err = client.New().URL("http://other-host/users/1").IntoStandard(&user).Do(context.Background())
+	True(t, err == nil)
+
+	_, err = httptest.NewRecorder(filepath.Join(dir, "none.json"), httptest.ModeReplay)
+	True(t, err != nil)
+}
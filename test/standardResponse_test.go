@@ -0,0 +1,89 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	goleHttp "github.com/isyscore/gole/http"
+)
+
+type standardUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func newStandardServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/int":
+			_, _ = w.Write([]byte(`{"code":200,"message":"ok","data":{"name":"gole","age":3}}`))
+		case "/string":
+			_, _ = w.Write([]byte(`{"code":"success","data":[{"name":"a"},{"name":"b"}]}`))
+		case "/bizInt":
+			_, _ = w.Write([]byte(`{"code":500,"message":"failed","data":{"name":"x"}}`))
+		case "/bizString":
+			_, _ = w.Write([]byte(`{"code":"NOT_FOUND","message":"user not found"}`))
+		case "/custom":
+			_, _ = w.Write([]byte(`{"code":1000,"message":"ok","data":1}`))
+		}
+	}))
+}
+
+func TestGetOfStandardInto(t *testing.T) {
+	server := newStandardServer()
+	defer server.Close()
+
+	user := standardUser{}
+	err := goleHttp.GetOfStandardInto(server.URL+"/int", &user)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, user.Name, "gole", user.Age, 3)
+
+	var users []standardUser
+	err = goleHttp.PostOfStandardInto(server.URL+"/string", nil, &users)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, len(users), 2, users[1].Name, "b")
+
+	data, _ := goleHttp.GetSimpleOfStandard(server.URL + "/int")
+	Equal(t, string(data), `{"name":"gole","age":3}`)
+}
+
+func TestStandardBizError(t *testing.T) {
+	server := newStandardServer()
+	defer server.Close()
+
+	_, err := goleHttp.GetSimpleOfStandard(server.URL + "/bizInt")
+	var bizError *goleHttp.BizError
+	True(t, errors.As(err, &bizError))
+	Equal(t, bizError.Code, "500", bizError.Message, "failed", string(bizError.Data), `{"name":"x"}`)
+
+	user := standardUser{}
+	_, err = goleHttp.New().URL(server.URL + "/bizString").IntoStandard(&user).Do(context.Background())
+	True(t, errors.As(err, &bizError))
+	Equal(t, bizError.Code, "NOT_FOUND", bizError.Message, "user not found")
+
+	err = goleHttp.GetOfStandardInto(server.URL+"/custom", nil)
+	True(t, errors.As(err, &bizError))
+
+	defer goleHttp.SetSuccessCodes("0", "200", "success")
+	goleHttp.SetSuccessCodes(1000)
+	// 返回的是副本，修改不影响成功的code
+	goleHttp.SuccessCodes()[0] = "1"
+	Equal(t, strings.Join(goleHttp.SuccessCodes(), ","), "1000")
+	var value int
+	err = goleHttp.GetOfStandardInto(server.URL+"/custom", &value)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, value, 1)
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	goleHttp "github.com/isyscore/gole/http"
+	"github.com/isyscore/gole/http/httptest"
+)
+
+func TestStubServer(t *testing.T) {
+	server := httptest.NewServer()
+	defer server.Close()
+
+	userRoute := server.On("GET", "/users/{id}").ReplySuccess(map[string]interface{}{"name": "gole"})
+	server.On("POST", "/users").WithHeader("token", "abc").Reply(http.StatusCreated, map[string]int{"id": 1})
+	server.On("GET", "/orders").WithQuery("status", "paid").ReplyFailed(4001, "no orders")
+	server.On("*", "/files/*").ReplyHeader("X-File", "yes").Reply(200, "file")
+
+	user := standardUser{}
+	err := goleHttp.GetOfStandardInto(server.URL+"/users/7", &user)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, user.Name, "gole", userRoute.Calls(), 1, userRoute.LastRequest().PathParams["id"], "7")
+
+	response, err := goleHttp.New().Method("POST").URL(server.URL+"/users").Header("token", "abc").JSON(map[string]string{"name": "new"}).Do(context.Background())
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, response.StatusCode, 201, response.String(), `{"id":1}`)
+
+	body := map[string]string{}
+	_ = server.Requests()[1].JSON(&body)
+	Equal(t, body["name"], "new")
+
+	// 请求头不匹配
+	_, err = goleHttp.PostSimple(server.URL+"/users", nil)
+	var statusError *goleHttp.StatusError
+	True(t, errors.As(err, &statusError))
+	Equal(t, statusError.StatusCode, 404, len(server.Unmatched()), 1)
+
+	_, err = goleHttp.Get(server.URL+"/orders", nil, map[string]string{"status": "paid"})
+	True(t, err == nil)
+	_, err = goleHttp.GetOfStandard(server.URL+"/orders", nil, map[string]string{"status": "paid"})
+	var bizError *goleHttp.BizError
+	True(t, errors.As(err, &bizError))
+	Equal(t, bizError.Code, "4001")
+
+	response, _ = goleHttp.New().Method("DELETE").URL(server.URL + "/files/a/b.txt").Do(context.Background())
+	Equal(t, response.String(), "file", response.Header.Get("X-File"), "yes")
+
+	server.AssertCalled(t, "GET", "/orders", 2)
+	server.AssertCalled(t, "", "/users", 2)
+
+	server.Reset()
+	server.On("GET", "/ok").ReplyFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("func"))
+	})
+	data, _ := goleHttp.GetSimple(server.URL + "/ok")
+	Equal(t, string(data), "func")
+	server.AssertAllCalled(t)
+}
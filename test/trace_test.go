@@ -0,0 +1,232 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	goleHttp "github.com/isyscore/gole/http"
+	"github.com/isyscore/gole/log"
+	"github.com/isyscore/gole/trace"
+	"github.com/isyscore/gole/web"
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+)
+
+type spanCollector struct {
+	lock  sync.Mutex
+	spans []*trace.Span
+}
+
+func (collector *spanCollector) Export(span *trace.Span) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	collector.spans = append(collector.spans, span)
+}
+
+func (collector *spanCollector) Spans() []*trace.Span {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	return append([]*trace.Span{}, collector.spans...)
+}
+
+func TestParseTraceparent(t *testing.T) {
+	spanContext, err := trace.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, spanContext.TraceId.String(), "4bf92f3577b34da6a3ce929d0e0e4736", spanContext.SpanId.String(), "00f067aa0ba902b7", spanContext.Sampled, true)
+	Equal(t, spanContext.Traceparent(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// 未来的版本可以有更多的段
+	spanContext, err = trace.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+	True(t, err == nil)
+	False(t, spanContext.Sampled)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, err := trace.ParseTraceparent(invalid)
+		True(t, err != nil)
+	}
+}
+
+func TestTracePropagation(t *testing.T) {
+	collector := &spanCollector{}
+	trace.SetExporter(collector)
+	defer trace.SetExporter(nil)
+
+	var downstreamHeader http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstreamHeader = r.Header.Clone()
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer downstream.Close()
+
+	logger, hook := logrusTest.NewNullLogger()
+	logger.AddHook(&log.TraceHook{})
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(web.TraceHandler())
+	engine.GET("/orders/:id", func(c *gin.Context) {
+		logger.WithContext(c).Info("query order")
+		if _, err := goleHttp.GetCtx(c, downstream.URL, nil, nil); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	request.Header.Set("tracestate", "vendor=value")
+	engine.ServeHTTP(recorder, request)
+	Equal(t, recorder.Code, http.StatusOK)
+
+	spans := collector.Spans()
+	Equal(t, len(spans), 2)
+	clientSpan, serverSpan := spans[0], spans[1]
+	Equal(t, serverSpan.Name, "GET /orders/:id", serverSpan.Kind, trace.SpanKindServer)
+	Equal(t, serverSpan.TraceId(), "4bf92f3577b34da6a3ce929d0e0e4736", serverSpan.ParentSpanId.String(), "00f067aa0ba902b7")
+	Equal(t, serverSpan.Attributes["http.status_code"], 200)
+	Equal(t, clientSpan.Kind, trace.SpanKindClient, clientSpan.TraceId(), serverSpan.TraceId(), clientSpan.ParentSpanId, serverSpan.SpanContext.SpanId)
+
+	// 下游收到的是client span
+	Equal(t, downstreamHeader.Get("traceparent"), "00-4bf92f3577b34da6a3ce929d0e0e4736-"+clientSpan.SpanId()+"-01")
+	Equal(t, downstreamHeader.Get("tracestate"), "vendor=value")
+
+	// 日志中有链路信息
+	entry := hook.LastEntry()
+	Equal(t, entry.Data["traceId"], serverSpan.TraceId(), entry.Data["spanId"], serverSpan.SpanId())
+}
+
+func TestTraceNewRootAndSample(t *testing.T) {
+	collector := &spanCollector{}
+	trace.SetExporter(collector)
+	defer trace.SetExporter(nil)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(web.TraceHandler())
+	engine.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	spans := collector.Spans()
+	Equal(t, len(spans), 1)
+	True(t, spans[0].SpanContext.TraceId.IsValid())
+	False(t, spans[0].ParentSpanId.IsValid())
+	Equal(t, spans[0].Err, "Internal Server Error")
+
+	// 不采样的不导出
+	trace.SetSampleRatio(0)
+	defer trace.SetSampleRatio(1)
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	Equal(t, len(collector.Spans()), 1)
+
+	// 超出范围的比例按照0或1
+	trace.SetSampleRatio(math.NaN())
+	_, span := trace.StartSpanWithParent(context.Background(), "nan", trace.SpanKindServer, trace.SpanContext{})
+	False(t, span.SpanContext.Sampled)
+	trace.SetSampleRatio(2)
+	_, span = trace.StartSpanWithParent(context.Background(), "over", trace.SpanKindServer, trace.SpanContext{})
+	True(t, span.SpanContext.Sampled)
+
+	// 没有链路的调用不注入
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer server.Close()
+	_, _ = goleHttp.GetSimple(server.URL)
+	Equal(t, header.Get("traceparent"), "")
+}
+
+func TestJsonFileExporter(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "trace", "trace.json")
+	fileExporter, err := trace.NewJsonFileExporter(filePath, "order-service")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	trace.SetExporter(fileExporter)
+	defer trace.SetExporter(nil)
+
+	ctx, parent := trace.StartSpan(context.Background(), "parent", trace.SpanKindInternal)
+	_, child := trace.StartSpan(ctx, "child", trace.SpanKindClient)
+	child.SetAttribute("count", 3)
+	child.End()
+	parent.End()
+	parent.End()
+	_ = fileExporter.Close()
+
+	file, _ := os.Open(filePath)
+	defer file.Close()
+	var records []trace.SpanRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record trace.SpanRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			Err(t, err)
+			return
+		}
+		records = append(records, record)
+	}
+	Equal(t, len(records), 2)
+	Equal(t, records[0].Name, "child", records[0].Kind, "client", records[0].ServiceName, "order-service", records[0].ParentSpanId, parent.SpanId())
+	Equal(t, records[0].Attributes["count"], float64(3), records[1].ParentSpanId, "")
+}
+
+func TestOtlpExporter(t *testing.T) {
+	var body map[string]interface{}
+	var contentType string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		data, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+	}))
+	defer collector.Close()
+
+	otlpExporter := trace.NewOtlpExporterWithInterval(collector.URL+"/v1/traces", "order-service", time.Hour)
+	_, span := trace.StartSpan(context.Background(), "GET /orders", trace.SpanKindServer)
+	span.SetAttribute("http.status_code", 500)
+	span.SetError(context.DeadlineExceeded)
+	span.End()
+	otlpExporter.Export(span)
+	if err := otlpExporter.Shutdown(); err != nil {
+		Err(t, err)
+		return
+	}
+
+	Equal(t, contentType, "application/json")
+	resourceSpans := body["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	resourceAttribute := resourceSpans["resource"].(map[string]interface{})["attributes"].([]interface{})[0].(map[string]interface{})
+	Equal(t, resourceAttribute["key"], "service.name", resourceAttribute["value"].(map[string]interface{})["stringValue"], "order-service")
+
+	otlpSpan := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})[0].(map[string]interface{})
+	Equal(t, otlpSpan["traceId"], span.TraceId(), otlpSpan["spanId"], span.SpanId(), otlpSpan["kind"], float64(2))
+	Equal(t, otlpSpan["status"].(map[string]interface{})["code"], float64(2))
+	True(t, strings.Contains(otlpSpan["status"].(map[string]interface{})["message"].(string), "deadline"))
+	attribute := otlpSpan["attributes"].([]interface{})[0].(map[string]interface{})
+	Equal(t, attribute["value"].(map[string]interface{})["intValue"], "500")
+}
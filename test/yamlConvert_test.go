@@ -0,0 +1,106 @@
+package test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/isyscore/gole/yaml"
+)
+
+func TestDetect(t *testing.T) {
+	Equal(t, yaml.Detect(""), yaml.STRING)
+	Equal(t, yaml.Detect("hello world"), yaml.STRING)
+	Equal(t, yaml.Detect("{\"a\": 1}"), yaml.JSON)
+	Equal(t, yaml.Detect("[1, 2, 3]"), yaml.JSON)
+	Equal(t, yaml.Detect("a.b=1\na.c=2"), yaml.PROPERTIES)
+	Equal(t, yaml.Detect("# comment\n! comment\na.b=1\n"), yaml.PROPERTIES)
+	Equal(t, yaml.Detect("url=http://localhost:8080"), yaml.PROPERTIES)
+	Equal(t, yaml.Detect("a.b:1"), yaml.PROPERTIES)
+	Equal(t, yaml.Detect("a.b=first \\\n  second"), yaml.PROPERTIES)
+	Equal(t, yaml.Detect("a:\n  b: 1"), yaml.YAML)
+	Equal(t, yaml.Detect("url: jdbc:mysql://localhost?a=b"), yaml.YAML)
+	Equal(t, yaml.Detect("- a\n- b"), yaml.YAML)
+
+	detectFileTest(t, "./resources/yml/base.yml", yaml.YAML)
+	detectFileTest(t, "./resources/yml/array1.yml", yaml.YAML)
+	detectFileTest(t, "./resources/yml/multi_line.yml", yaml.YAML)
+	detectFileTest(t, "./resources/properties/base.properties", yaml.PROPERTIES)
+	detectFileTest(t, "./resources/properties/array1.properties", yaml.PROPERTIES)
+	detectFileTest(t, "./resources/json/test.json", yaml.JSON)
+}
+
+func TestConvert(t *testing.T) {
+	act, err := yaml.Convert("a:\n  b: 1\n  c:\n  - x1\n  - x2\n", yaml.YAML, yaml.JSON)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	jsonEqual(t, act, `{"a":{"b":1,"c":["x1","x2"]}}`)
+
+	act, err = yaml.Convert("a.b=1\na.c[0]=x1\na.c[1]=x2", yaml.PROPERTIES, yaml.JSON)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	jsonEqual(t, act, `{"a":{"b":1,"c":["x1","x2"]}}`)
+
+	act, err = yaml.Convert(`{"a":{"b":1}}`, yaml.JSON, yaml.PROPERTIES)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, strings.TrimSpace(act), "a.b=1")
+
+	act, err = yaml.Convert(`{"a":{"b":1}}`, yaml.JSON, yaml.YAML)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, strings.TrimSpace(act), "a:\n  b: 1")
+
+	act, err = yaml.Convert("a.b=1", yaml.PROPERTIES, yaml.YAML)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, strings.TrimSpace(act), "a:\n  b: 1")
+
+	act, err = yaml.Convert("a:\n  b: 1", yaml.YAML, yaml.PROPERTIES)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, strings.TrimSpace(act), "a.b=1")
+
+	act, _ = yaml.Convert("text", yaml.STRING, yaml.STRING)
+	Equal(t, act, "text")
+
+	_, err = yaml.Convert("text", yaml.STRING, yaml.YAML)
+	True(t, err != nil)
+}
+
+func detectFileTest(t *testing.T, filePath string, expect yaml.Format) {
+	bytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, yaml.Detect(string(bytes)), expect)
+}
+
+func jsonEqual(t *testing.T, act, expect string) {
+	var actData, expectData interface{}
+	if err := json.Unmarshal([]byte(act), &actData); err != nil {
+		Err(t, err)
+		return
+	}
+	if err := json.Unmarshal([]byte(expect), &expectData); err != nil {
+		Err(t, err)
+		return
+	}
+	actBytes, _ := json.Marshal(actData)
+	expectBytes, _ := json.Marshal(expectData)
+	Equal(t, string(actBytes), string(expectBytes))
+}
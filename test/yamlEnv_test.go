@@ -0,0 +1,145 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/isyscore/gole/yaml"
+)
+
+func TestMapToEnv(t *testing.T) {
+	dataMap, err := yaml.YamlToMap(`
+server:
+  port: 8080
+  context-path: /api
+  hosts:
+  - name: a
+  - name: b
+desc: |
+  line1
+  line2
+`)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+
+	// env-file不支持多行的值
+	_, err = yaml.MapToEnv(dataMap, "app")
+	True(t, err != nil)
+
+	act, err := yaml.MapToEnvQuoted(dataMap, "app")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	expect := "APP_DESC=\"line1\\nline2\\n\"\n" +
+		"APP_SERVER_CONTEXT_PATH=/api\n" +
+		"APP_SERVER_HOSTS_0_NAME=a\n" +
+		"APP_SERVER_HOSTS_1_NAME=b\n" +
+		"APP_SERVER_PORT=8080\n"
+	Equal(t, act, expect)
+
+	act, _ = yaml.MapToEnv(map[string]interface{}{"a.b": []int{1, 2}}, "")
+	Equal(t, act, "A_B_0=1\nA_B_1=2\n")
+}
+
+func TestEnvToMap(t *testing.T) {
+	content := "# comment\n" +
+		"APP_SERVER_PORT=8080\n" +
+		"export APP_SERVER_HOSTS_1_NAME=b\n" +
+		"APP_SERVER_HOSTS_0_NAME=\"a\"\n" +
+		"APP_TAGS_0='x=1'\n" +
+		"OTHER_KEY=ignore\n"
+
+	act, err := yaml.EnvToMap(content, "app")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+
+	server := act["server"].(map[string]interface{})
+	Equal(t, server["port"], "8080")
+	hosts := server["hosts"].([]interface{})
+	Equal(t, len(hosts), 2)
+	Equal(t, hosts[0].(map[string]interface{})["name"], "a")
+	Equal(t, hosts[1].(map[string]interface{})["name"], "b")
+	Equal(t, act["tags"].([]interface{})[0], "x=1")
+	_, exist := act["other"]
+	False(t, exist)
+
+	all, _ := yaml.EnvToMap(content, "")
+	_, exist = all["other"]
+	True(t, exist)
+}
+
+func TestEnvRoundTrip(t *testing.T) {
+	dataMap := map[string]interface{}{
+		"server": map[string]interface{}{
+			"port":  "8080",
+			"hosts": []interface{}{"a", "b"},
+		},
+	}
+	env, _ := yaml.MapToEnv(dataMap, "gole")
+	act, err := yaml.EnvToMap(env, "gole")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+
+	expectProperties, _ := yaml.MapToProperties(dataMap)
+	actProperties, _ := yaml.MapToProperties(act)
+	expectMap, _ := yaml.PropertiesToMap(expectProperties)
+	actMap, _ := yaml.PropertiesToMap(actProperties)
+	Equal(t, len(actMap), len(expectMap))
+	for key, value := range expectMap {
+		Equal(t, actMap[key], value)
+	}
+}
+
+func TestEnvRoundTripEscape(t *testing.T) {
+	dataMap := map[string]interface{}{
+		"multi":  "line1\nline2\r\n",
+		"path":   "c:\\new\\table",
+		"quote":  "say \"hi\"",
+		"single": "'x'",
+		"blank":  "  padded ",
+		"plain":  "a=b",
+	}
+	env, _ := yaml.MapToEnvQuoted(dataMap, "")
+	True(t, strings.Contains(env, "PATH=c:\\new\\table\n"))
+	True(t, strings.Contains(env, "MULTI=\"line1\\nline2\\r\\n\"\n"))
+
+	act, err := yaml.EnvToMap(env, "")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	for key, value := range dataMap {
+		Equal(t, act[key], value)
+	}
+}
+
+// docker的--env-file不去掉引号，值原样输出
+func TestEnvFileRoundTrip(t *testing.T) {
+	dataMap := map[string]interface{}{
+		"greeting": "hello world",
+		"path":     "c:\\new\\table",
+		"json":     `{"a": "b c"}`,
+	}
+	env, err := yaml.MapToEnv(dataMap, "app")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, env, "APP_GREETING=hello world\n"+`APP_JSON={"a": "b c"}`+"\n"+"APP_PATH=c:\\new\\table\n")
+
+	act, err := yaml.EnvToMap(env, "app")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	for key, value := range dataMap {
+		Equal(t, act[key], value)
+	}
+}
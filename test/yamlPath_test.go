@@ -0,0 +1,171 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/isyscore/gole/yaml"
+)
+
+var pathYaml = `server:
+  port: 8080
+  hosts:
+  - name: a
+    ip: 10.0.0.1
+  - name: b
+    ip: 10.0.0.2
+matrix:
+- - 1
+  - 2
+- - 3
+  - 4
+`
+
+func TestYamlGet(t *testing.T) {
+	value, err := yaml.Get(pathYaml, "server.port")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, value, 8080)
+
+	value, _ = yaml.Get(pathYaml, "server.hosts[1].ip")
+	Equal(t, value, "10.0.0.2")
+
+	value, _ = yaml.Get(pathYaml, "matrix[1][0]")
+	Equal(t, value, 3)
+
+	value, _ = yaml.Get(pathYaml, "server.hosts[0]")
+	hostMap := value.(map[string]interface{})
+	Equal(t, hostMap["name"], "a")
+
+	value, _ = yaml.Get(pathYaml, "server.hosts[5].ip")
+	True(t, value == nil)
+
+	value, _ = yaml.Get(`{"a":{"b":[1,{"c":"json"}]}}`, "a.b[1].c")
+	Equal(t, value, "json")
+
+	value, _ = yaml.Get("a.b[0]=x\na.b[1]=y1", "a.b[1]")
+	Equal(t, value, "y1")
+
+	_, err = yaml.Get(pathYaml, "server..port")
+	True(t, err != nil)
+	_, err = yaml.Get(pathYaml, "server.hosts[x]")
+	True(t, err != nil)
+}
+
+func TestYamlSet(t *testing.T) {
+	act, err := yaml.Set(pathYaml, "server.hosts[1].ip", "10.0.0.3")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	value, _ := yaml.Get(act, "server.hosts[1].ip")
+	Equal(t, value, "10.0.0.3")
+	// key的顺序保持不变
+	True(t, strings.Index(act, "server:") < strings.Index(act, "matrix:"))
+	True(t, strings.Index(act, "port:") < strings.Index(act, "hosts:"))
+
+	act, _ = yaml.Set(pathYaml, "server.hosts[2].name", "c")
+	value, _ = yaml.Get(act, "server.hosts[2].name")
+	Equal(t, value, "c")
+
+	act, _ = yaml.Set(pathYaml, "server.ssl.enable", true)
+	value, _ = yaml.Get(act, "server.ssl.enable")
+	Equal(t, value, true)
+
+	act, _ = yaml.Set(pathYaml, "server.tags", []string{"x1", "x2"})
+	value, _ = yaml.Get(act, "server.tags[1]")
+	Equal(t, value, "x2")
+
+	_, err = yaml.Set(pathYaml, "server.hosts[9].name", "c")
+	True(t, err != nil)
+	_, err = yaml.Set(pathYaml, "server.port.x", 1)
+	True(t, err != nil)
+
+	act, _ = yaml.Set(`{"b":1,"a":{"c":2}}`, "a.c", 3)
+	Equal(t, act, `{"b":1,"a":{"c":3}}`)
+
+	act, _ = yaml.Set("{\n  \"a\": 1\n}", "b", "x")
+	Equal(t, act, "{\n  \"a\": 1,\n  \"b\": \"x\"\n}")
+}
+
+func TestYamlDelete(t *testing.T) {
+	act, err := yaml.Delete(pathYaml, "server.hosts[0]")
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	value, _ := yaml.Get(act, "server.hosts[0].name")
+	Equal(t, value, "b")
+
+	act, _ = yaml.Delete(pathYaml, "server.port")
+	value, _ = yaml.Get(act, "server.port")
+	True(t, value == nil)
+
+	act, _ = yaml.Delete(pathYaml, "server.none")
+	Equal(t, act, pathYaml)
+
+	act, _ = yaml.Delete(`{"a":1,"b":[1,2,3]}`, "b[1]")
+	Equal(t, act, `{"a":1,"b":[1,3]}`)
+}
+
+func TestApplyJsonPatch(t *testing.T) {
+	content := `{"foo":"bar","list":[1,2],"obj":{"a/b":1,"m~n":2}}`
+
+	act, err := yaml.ApplyJsonPatch(content, `[
+		{"op":"add","path":"/baz","value":"qux"},
+		{"op":"add","path":"/list/1","value":9},
+		{"op":"add","path":"/list/-","value":3},
+		{"op":"replace","path":"/foo","value":{"x":1}},
+		{"op":"remove","path":"/obj/a~1b"},
+		{"op":"copy","from":"/obj/m~0n","path":"/copied"},
+		{"op":"move","from":"/baz","path":"/moved"},
+		{"op":"test","path":"/list","value":[1,9,2,3]}
+	]`)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, act, `{"foo":{"x":1},"list":[1,9,2,3],"obj":{"m~n":2},"copied":2,"moved":"qux"}`)
+
+	_, err = yaml.ApplyJsonPatch(content, `[{"op":"test","path":"/foo","value":"baz"}]`)
+	True(t, err != nil)
+	_, err = yaml.ApplyJsonPatch(content, `[{"op":"remove","path":"/none"}]`)
+	True(t, err != nil)
+	_, err = yaml.ApplyJsonPatch(content, `[{"op":"replace","path":"/none","value":1}]`)
+	True(t, err != nil)
+	_, err = yaml.ApplyJsonPatch(content, `[{"op":"add","path":"/list/5","value":1}]`)
+	True(t, err != nil)
+	_, err = yaml.ApplyJsonPatch(content, `[{"op":"move","from":"/obj","path":"/obj/child"}]`)
+	True(t, err != nil)
+
+	// yaml文档同样适用
+	act, err = yaml.ApplyJsonPatch(pathYaml, `[{"op":"replace","path":"/server/hosts/0/ip","value":"127.0.0.1"}]`)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	value, _ := yaml.Get(act, "server.hosts[0].ip")
+	Equal(t, value, "127.0.0.1")
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	act, err := yaml.ApplyMergePatch(`{"a":"b","c":{"d":"e","f":"g"}}`, `{"a":"z","c":{"f":null}}`)
+	if err != nil {
+		Err(t, err)
+		return
+	}
+	Equal(t, act, `{"a":"z","c":{"d":"e"}}`)
+
+	act, _ = yaml.ApplyMergePatch(`{"a":[1,2]}`, `{"a":[3],"b":{"c":null,"d":1}}`)
+	Equal(t, act, `{"a":[3],"b":{"d":1}}`)
+
+	act, _ = yaml.ApplyMergePatch(pathYaml, "server:\n  port: 9090\nmatrix: null\n")
+	value, _ := yaml.Get(act, "server.port")
+	Equal(t, value, 9090)
+	value, _ = yaml.Get(act, "matrix")
+	True(t, value == nil)
+	value, _ = yaml.Get(act, "server.hosts[1].name")
+	Equal(t, value, "b")
+}
@@ -0,0 +1,316 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Exporter span的导出器，span结束时同步调用，需要尽快返回
+type Exporter interface {
+	Export(span *Span)
+}
+
+var exporter Exporter
+var exporterLock sync.RWMutex
+var sampleRatio = 1.0
+
+// SetExporter 设置导出器，nil则不导出
+func SetExporter(spanExporter Exporter) {
+	exporterLock.Lock()
+	defer exporterLock.Unlock()
+	exporter = spanExporter
+}
+
+// SetSampleRatio 新建的链路的采样比例，取值0~1，超出范围的按照0或1；上游传入的链路按照上游的采样标记
+func SetSampleRatio(ratio float64) {
+	if !(ratio >= 0) {
+		ratio = 0
+	} else if ratio > 1 {
+		ratio = 1
+	}
+	exporterLock.Lock()
+	defer exporterLock.Unlock()
+	sampleRatio = ratio
+}
+
+func getSampleRatio() float64 {
+	exporterLock.RLock()
+	defer exporterLock.RUnlock()
+	return sampleRatio
+}
+
+func export(span *Span) {
+	exporterLock.RLock()
+	spanExporter := exporter
+	exporterLock.RUnlock()
+	if spanExporter != nil {
+		spanExporter.Export(span)
+	}
+}
+
+// SpanRecord span导出到文件的格式
+type SpanRecord struct {
+	ServiceName  string                 `json:"serviceName,omitempty"`
+	TraceId      string                 `json:"traceId"`
+	SpanId       string                 `json:"spanId"`
+	ParentSpanId string                 `json:"parentSpanId,omitempty"`
+	Name         string                 `json:"name"`
+	Kind         string                 `json:"kind"`
+	StartTime    time.Time              `json:"startTime"`
+	EndTime      time.Time              `json:"endTime"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Err          string                 `json:"err,omitempty"`
+}
+
+func recordOf(span *Span, serviceName string) *SpanRecord {
+	span.lock.Lock()
+	defer span.lock.Unlock()
+
+	record := &SpanRecord{
+		ServiceName: serviceName,
+		TraceId:     span.TraceId(),
+		SpanId:      span.SpanId(),
+		Name:        span.Name,
+		Kind:        span.Kind.String(),
+		StartTime:   span.StartTime,
+		EndTime:     span.EndTime,
+		Attributes:  map[string]interface{}{},
+		Err:         span.Err,
+	}
+	if span.ParentSpanId.IsValid() {
+		record.ParentSpanId = span.ParentSpanId.String()
+	}
+	for key, value := range span.Attributes {
+		record.Attributes[key] = value
+	}
+	return record
+}
+
+func (kind SpanKind) String() string {
+	switch kind {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	}
+	return "internal"
+}
+
+// ------------------ file ------------------
+
+// JsonFileExporter 将span以json追加到文件中，每行一个
+type JsonFileExporter struct {
+	lock        sync.Mutex
+	file        *os.File
+	serviceName string
+}
+
+// NewJsonFileExporter 创建文件导出器，目录不存在时创建
+func NewJsonFileExporter(filePath, serviceName string) (*JsonFileExporter, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &JsonFileExporter{file: file, serviceName: serviceName}, nil
+}
+
+func (fileExporter *JsonFileExporter) Export(span *Span) {
+	content, err := json.Marshal(recordOf(span, fileExporter.serviceName))
+	if err != nil {
+		return
+	}
+	fileExporter.lock.Lock()
+	defer fileExporter.lock.Unlock()
+	_, _ = fileExporter.file.Write(append(content, '\n'))
+}
+
+// Close 关闭文件
+func (fileExporter *JsonFileExporter) Close() error {
+	fileExporter.lock.Lock()
+	defer fileExporter.lock.Unlock()
+	return fileExporter.file.Close()
+}
+
+// ------------------ otlp ------------------
+
+// OtlpExporter 通过OTLP/HTTP（json）批量发送到collector，后台每隔FlushInterval或者攒够BatchSize个发送一次
+type OtlpExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+
+	lock      sync.Mutex
+	spans     []*Span
+	batchSize int
+	maxQueue  int
+	flushCh   chan struct{}
+	closeCh   chan struct{}
+	done      chan struct{}
+}
+
+// NewOtlpExporter 创建otlp导出器，endpoint比如http://localhost:4318/v1/traces；不用时需要Shutdown
+func NewOtlpExporter(endpoint, serviceName string) *OtlpExporter {
+	return NewOtlpExporterWithInterval(endpoint, serviceName, 5*time.Second)
+}
+
+// NewOtlpExporterWithInterval 创建otlp导出器并指定发送间隔
+func NewOtlpExporterWithInterval(endpoint, serviceName string, flushInterval time.Duration) *OtlpExporter {
+	otlpExporter := &OtlpExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		batchSize:   512,
+		maxQueue:    2048,
+		flushCh:     make(chan struct{}, 1),
+		closeCh:     make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go otlpExporter.run(flushInterval)
+	return otlpExporter
+}
+
+// Export 放入队列，队列满时丢弃
+func (otlpExporter *OtlpExporter) Export(span *Span) {
+	otlpExporter.lock.Lock()
+	defer otlpExporter.lock.Unlock()
+
+	if len(otlpExporter.spans) >= otlpExporter.maxQueue {
+		return
+	}
+	otlpExporter.spans = append(otlpExporter.spans, span)
+	if len(otlpExporter.spans) >= otlpExporter.batchSize {
+		select {
+		case otlpExporter.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush 立即发送队列中的span
+func (otlpExporter *OtlpExporter) Flush() error {
+	otlpExporter.lock.Lock()
+	spans := otlpExporter.spans
+	otlpExporter.spans = nil
+	otlpExporter.lock.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+	content, err := json.Marshal(otlpExporter.request(spans))
+	if err != nil {
+		return err
+	}
+	rsp, err := otlpExporter.client.Post(otlpExporter.endpoint, "application/json", bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	body, _ := ioutil.ReadAll(rsp.Body)
+	if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices {
+		return errors.New("export spans to " + otlpExporter.endpoint + " failed, status: " + strconv.Itoa(rsp.StatusCode) + ", body: " + string(body))
+	}
+	return nil
+}
+
+// Shutdown 停止后台发送，并发送剩余的span
+func (otlpExporter *OtlpExporter) Shutdown() error {
+	select {
+	case <-otlpExporter.closeCh:
+	default:
+		close(otlpExporter.closeCh)
+	}
+	<-otlpExporter.done
+	return otlpExporter.Flush()
+}
+
+func (otlpExporter *OtlpExporter) run(flushInterval time.Duration) {
+	defer close(otlpExporter.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-otlpExporter.closeCh:
+			return
+		case <-ticker.C:
+		case <-otlpExporter.flushCh:
+		}
+		_ = otlpExporter.Flush()
+	}
+}
+
+// otlp的json格式：resourceSpans -> scopeSpans -> spans，id为16进制，时间为纳秒的字符串
+func (otlpExporter *OtlpExporter) request(spans []*Span) map[string]interface{} {
+	var otlpSpans []interface{}
+	for _, span := range spans {
+		record := recordOf(span, otlpExporter.serviceName)
+		otlpSpan := map[string]interface{}{
+			"traceId":           record.TraceId,
+			"spanId":            record.SpanId,
+			"name":              record.Name,
+			"kind":              int(span.Kind),
+			"startTimeUnixNano": strconv.FormatInt(record.StartTime.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(record.EndTime.UnixNano(), 10),
+			"attributes":        otlpAttributes(record.Attributes),
+			"status":            map[string]interface{}{"code": 0},
+		}
+		if record.ParentSpanId != "" {
+			otlpSpan["parentSpanId"] = record.ParentSpanId
+		}
+		if record.Err != "" {
+			otlpSpan["status"] = map[string]interface{}{"code": 2, "message": record.Err}
+		}
+		otlpSpans = append(otlpSpans, otlpSpan)
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": otlpAttributes(map[string]interface{}{"service.name": otlpExporter.serviceName}),
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": "github.com/isyscore/gole/trace"},
+				"spans": otlpSpans,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attributes map[string]interface{}) []interface{} {
+	result := []interface{}{}
+	for key, value := range attributes {
+		var otlpValue map[string]interface{}
+		switch data := value.(type) {
+		case bool:
+			otlpValue = map[string]interface{}{"boolValue": data}
+		case int:
+			otlpValue = map[string]interface{}{"intValue": strconv.Itoa(data)}
+		case int64:
+			otlpValue = map[string]interface{}{"intValue": strconv.FormatInt(data, 10)}
+		case float64:
+			otlpValue = map[string]interface{}{"doubleValue": data}
+		default:
+			otlpValue = map[string]interface{}{"stringValue": toString(data)}
+		}
+		result = append(result, map[string]interface{}{"key": key, "value": otlpValue})
+	}
+	return result
+}
+
+func toString(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	content, _ := json.Marshal(value)
+	return string(content)
+}
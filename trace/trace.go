@@ -0,0 +1,266 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathRand "math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * 链路追踪：W3C Trace Context（traceparent、tracestate）
+ * 入口通过web.TraceHandler从请求头中提取或者新建链路，http调用时注入到请求头中，日志中通过WithContext记录traceId和spanId
+ */
+
+const (
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+)
+
+// SpanKind span的类型
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = iota + 1
+	SpanKindServer
+	SpanKindClient
+)
+
+// TraceId 16字节的链路id
+type TraceId [16]byte
+
+// SpanId 8字节的span id
+type SpanId [8]byte
+
+func (traceId TraceId) String() string {
+	return hex.EncodeToString(traceId[:])
+}
+
+// IsValid 全0为无效
+func (traceId TraceId) IsValid() bool {
+	return traceId != TraceId{}
+}
+
+func (spanId SpanId) String() string {
+	return hex.EncodeToString(spanId[:])
+}
+
+// IsValid 全0为无效
+func (spanId SpanId) IsValid() bool {
+	return spanId != SpanId{}
+}
+
+// SpanContext 跨进程传递的span信息
+type SpanContext struct {
+	TraceId TraceId
+	SpanId  SpanId
+	// 是否采样，没有采样的span不导出
+	Sampled bool
+	// tracestate原样传递
+	TraceState string
+}
+
+// IsValid traceId和spanId都有效
+func (spanContext SpanContext) IsValid() bool {
+	return spanContext.TraceId.IsValid() && spanContext.SpanId.IsValid()
+}
+
+// Traceparent traceparent请求头的值：00-traceId-spanId-flags
+func (spanContext SpanContext) Traceparent() string {
+	flags := "00"
+	if spanContext.Sampled {
+		flags = "01"
+	}
+	return "00-" + spanContext.TraceId.String() + "-" + spanContext.SpanId.String() + "-" + flags
+}
+
+// ParseTraceparent 解析traceparent请求头
+func ParseTraceparent(traceparent string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, errors.New("invalid traceparent: " + traceparent)
+	}
+	// 版本00只有4段，ff为无效版本
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, errors.New("invalid traceparent version: " + traceparent)
+	}
+
+	spanContext := SpanContext{}
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || len(version) != 1 {
+		return SpanContext{}, errors.New("invalid traceparent version: " + traceparent)
+	}
+	if _, err := hex.Decode(spanContext.TraceId[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, errors.New("invalid trace id: " + traceparent)
+	}
+	if _, err := hex.Decode(spanContext.SpanId[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, errors.New("invalid span id: " + traceparent)
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return SpanContext{}, errors.New("invalid trace flags: " + traceparent)
+	}
+	if !spanContext.IsValid() {
+		return SpanContext{}, errors.New("invalid traceparent, id is zero: " + traceparent)
+	}
+	spanContext.Sampled = flags[0]&1 == 1
+	return spanContext, nil
+}
+
+// Extract 从请求头中提取上游的span信息，没有或者格式不对时返回false
+func Extract(header http.Header) (SpanContext, bool) {
+	spanContext, err := ParseTraceparent(header.Get(HeaderTraceparent))
+	if err != nil {
+		return SpanContext{}, false
+	}
+	spanContext.TraceState = header.Get(HeaderTracestate)
+	return spanContext, true
+}
+
+// Inject 将ctx中的span注入到请求头中，没有span则不注入
+func Inject(ctx context.Context, header http.Header) {
+	span := FromContext(ctx)
+	if span == nil {
+		return
+	}
+	header.Set(HeaderTraceparent, span.SpanContext.Traceparent())
+	if span.SpanContext.TraceState != "" {
+		header.Set(HeaderTracestate, span.SpanContext.TraceState)
+	}
+}
+
+// ------------------ span ------------------
+
+// Span 一次调用
+type Span struct {
+	Name         string
+	Kind         SpanKind
+	SpanContext  SpanContext
+	ParentSpanId SpanId
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   map[string]interface{}
+	// 错误信息，不为空表示失败
+	Err string
+
+	lock  sync.Mutex
+	ended bool
+}
+
+type spanKey struct{}
+
+// StartSpan 创建span：ctx中有span时作为子span，否则新建链路
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if parent := FromContext(ctx); parent != nil {
+		return StartSpanWithParent(ctx, name, kind, parent.SpanContext)
+	}
+	return StartSpanWithParent(ctx, name, kind, SpanContext{})
+}
+
+// StartSpanWithParent 以parent为父span创建span，parent无效时新建链路，并按照采样比例决定是否采样
+func StartSpanWithParent(ctx context.Context, name string, kind SpanKind, parent SpanContext) (context.Context, *Span) {
+	span := &Span{Name: name, Kind: kind, StartTime: time.Now(), Attributes: map[string]interface{}{}}
+	if parent.IsValid() {
+		span.SpanContext = SpanContext{TraceId: parent.TraceId, Sampled: parent.Sampled, TraceState: parent.TraceState}
+		span.ParentSpanId = parent.SpanId
+	} else {
+		ratio := getSampleRatio()
+		span.SpanContext = SpanContext{TraceId: newTraceId(), Sampled: ratio >= 1 || mathRand.Float64() < ratio}
+	}
+	span.SpanContext.SpanId = newSpanId()
+	return ContextWithSpan(ctx, span), span
+}
+
+// ContextWithSpan 将span放到ctx中
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// FromContext ctx中的span，没有则返回nil；ctx为gin.Context时从其中的请求获取
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	if span, ok := ctx.Value(spanKey{}).(*Span); ok {
+		return span
+	}
+	// gin.Context的Value(0)为其中的*http.Request
+	if request, ok := ctx.Value(0).(*http.Request); ok && request != nil {
+		if span, ok := request.Context().Value(spanKey{}).(*Span); ok {
+			return span
+		}
+	}
+	return nil
+}
+
+// TraceId 链路id
+func (span *Span) TraceId() string {
+	return span.SpanContext.TraceId.String()
+}
+
+// SpanId span id
+func (span *Span) SpanId() string {
+	return span.SpanContext.SpanId.String()
+}
+
+// SetAttribute 设置属性，值为string、bool、int、int64、float64
+func (span *Span) SetAttribute(key string, value interface{}) {
+	span.lock.Lock()
+	defer span.lock.Unlock()
+	span.Attributes[key] = value
+}
+
+// SetError 记录错误，span标记为失败
+func (span *Span) SetError(err error) {
+	if err == nil {
+		return
+	}
+	span.lock.Lock()
+	defer span.lock.Unlock()
+	span.Err = err.Error()
+}
+
+// End 结束span，采样的span交给导出器，多次调用只生效一次
+func (span *Span) End() {
+	span.lock.Lock()
+	if span.ended {
+		span.lock.Unlock()
+		return
+	}
+	span.ended = true
+	span.EndTime = time.Now()
+	span.lock.Unlock()
+
+	if span.SpanContext.Sampled {
+		export(span)
+	}
+}
+
+func (span *Span) String() string {
+	return fmt.Sprintf("%v(traceId=%v, spanId=%v)", span.Name, span.TraceId(), span.SpanId())
+}
+
+func newTraceId() TraceId {
+	var traceId TraceId
+	for !traceId.IsValid() {
+		_, _ = rand.Read(traceId[:])
+	}
+	return traceId
+}
+
+func newSpanId() SpanId {
+	var spanId SpanId
+	for !spanId.IsValid() {
+		_, _ = rand.Read(spanId[:])
+	}
+	return spanId
+}
@@ -0,0 +1,50 @@
+package trace
+
+import (
+	"github.com/isyscore/gole/config"
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	config.LoadConfig()
+	loadConfig()
+}
+
+// 读取base.trace的配置
+func loadConfig() {
+	if config.GetValue("base.trace") == nil {
+		return
+	}
+	if err := config.GetValueObject("base.trace", &config.TraceCfg); err != nil {
+		logrus.Errorf("read base.trace config err, %v", err.Error())
+		return
+	}
+
+	if config.GetValue("base.trace.sampleRatio") != nil {
+		SetSampleRatio(config.TraceCfg.SampleRatio)
+	}
+	serviceName := config.TraceCfg.ServiceName
+	if serviceName == "" {
+		serviceName = config.GetValueString("base.application.name")
+	}
+
+	switch config.TraceCfg.Exporter {
+	case "otlp":
+		endpoint := config.TraceCfg.OtlpEndpoint
+		if endpoint == "" {
+			endpoint = "http://localhost:4318/v1/traces"
+		}
+		SetExporter(NewOtlpExporter(endpoint, serviceName))
+	case "file":
+		filePath := config.TraceCfg.FilePath
+		if filePath == "" {
+			filePath = "./logs/trace.json"
+		}
+		fileExporter, err := NewJsonFileExporter(filePath, serviceName)
+		if err != nil {
+			logrus.Errorf("create trace file exporter err, %v", err.Error())
+			return
+		}
+		SetExporter(fileExporter)
+	}
+}
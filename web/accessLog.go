@@ -0,0 +1,234 @@
+package web
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/isyscore/gole/config"
+	"github.com/isyscore/gole/log"
+	"github.com/isyscore/gole/yaml"
+	"github.com/sirupsen/logrus"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// AccessLogOptions 访问日志的配置
+type AccessLogOptions struct {
+	// 是否记录所有请求，false则只记录异常的请求
+	Enable bool
+	// 正常请求的采样比例，取值0~1，异常的请求都记录
+	SampleRatio float64
+	// 是否记录请求头
+	ShowHead bool
+	// 记录的请求体和响应体的最大长度，超过则截断
+	MaxBodySize int
+	// 替换为***的请求头
+	RedactHeaders []string
+	// 替换为***的json路径，比如：user.password、items[*].token
+	RedactJsonPaths []string
+	// 替换为***的url参数和表单参数
+	RedactQueries []string
+}
+
+// 替换敏感内容的值
+const redacted = "***"
+
+var accessLogOptions = DefaultAccessLogOptions()
+var accessLogOptionsLock sync.RWMutex
+var accessLoggerName = "gole-access"
+var customAccessLogger *logrus.Logger
+var fileAccessLogger *logrus.Logger
+var accessLoggerLock sync.Mutex
+var consoleAccessLogger = newConsoleAccessLogger()
+
+func init() {
+	config.LoadConfig()
+	loadAccessLogConfig(nil)
+	config.AddChangeListener("base.server.accessLog", loadAccessLogConfig)
+}
+
+// DefaultAccessLogOptions 默认的访问日志配置：只记录异常的请求，不记录请求头
+func DefaultAccessLogOptions() *AccessLogOptions {
+	return &AccessLogOptions{SampleRatio: 1, MaxBodySize: 1024, RedactHeaders: []string{"Authorization", "Cookie", "Set-Cookie"}}
+}
+
+// SetAccessLogOptions 设置访问日志的配置，nil则使用默认配置
+func SetAccessLogOptions(options *AccessLogOptions) {
+	if options == nil {
+		options = DefaultAccessLogOptions()
+	}
+	accessLogOptionsLock.Lock()
+	defer accessLogOptionsLock.Unlock()
+	accessLogOptions = options
+}
+
+func getAccessLogOptions() *AccessLogOptions {
+	accessLogOptionsLock.RLock()
+	defer accessLogOptionsLock.RUnlock()
+	return accessLogOptions
+}
+
+// SetAccessLogger 设置访问日志，nil则使用log.GetLogger获取的日志
+func SetAccessLogger(logger *logrus.Logger) {
+	accessLoggerLock.Lock()
+	defer accessLoggerLock.Unlock()
+	customAccessLogger = logger
+}
+
+// SetAccessLoggerName 设置通过log.GetLogger获取的访问日志的名字，默认gole-access
+func SetAccessLoggerName(name string) {
+	accessLoggerLock.Lock()
+	defer accessLoggerLock.Unlock()
+	accessLoggerName = name
+	fileAccessLogger = nil
+}
+
+// 日志文件没有配置时（log.GetLogger返回nil）输出到控制台
+func getAccessLogger() *logrus.Logger {
+	accessLoggerLock.Lock()
+	defer accessLoggerLock.Unlock()
+
+	if customAccessLogger != nil {
+		return customAccessLogger
+	}
+	if fileAccessLogger == nil {
+		fileAccessLogger = log.GetLogger(accessLoggerName)
+	}
+	if fileAccessLogger != nil {
+		return fileAccessLogger
+	}
+	return consoleAccessLogger
+}
+
+func newConsoleAccessLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+	logger.Formatter = &log.StandardFormatter{}
+	logger.AddHook(&log.TraceHook{})
+	return logger
+}
+
+// 读取base.server.accessLog的配置，运行时修改立即生效
+func loadAccessLogConfig([]string) {
+	if config.GetValue("base.server.accessLog") == nil {
+		return
+	}
+	accessLogConfig := config.BaseAccessLog{}
+	if err := config.GetValueObject("base.server.accessLog", &accessLogConfig); err != nil {
+		getAccessLogger().Errorf("read base.server.accessLog config err, %v", err.Error())
+		return
+	}
+	config.BaseCfg.Server.AccessLog = accessLogConfig
+
+	if accessLogConfig.LoggerName != "" {
+		SetAccessLoggerName(accessLogConfig.LoggerName)
+	}
+	options := DefaultAccessLogOptions()
+	options.Enable = accessLogConfig.Enable
+	if config.GetValue("base.server.accessLog.sampleRatio") != nil {
+		options.SampleRatio = accessLogConfig.SampleRatio
+	}
+	options.ShowHead = accessLogConfig.ShowHead
+	if accessLogConfig.MaxBodySize > 0 {
+		options.MaxBodySize = accessLogConfig.MaxBodySize
+	}
+	if len(accessLogConfig.RedactHeaders) > 0 {
+		options.RedactHeaders = accessLogConfig.RedactHeaders
+	}
+	options.RedactJsonPaths = accessLogConfig.RedactJsonPaths
+	options.RedactQueries = accessLogConfig.RedactQueries
+	SetAccessLogOptions(options)
+}
+
+// 正常的请求是否记录
+func (options *AccessLogOptions) sampled() bool {
+	return options.Enable && (options.SampleRatio >= 1 || rand.Float64() < options.SampleRatio)
+}
+
+func (options *AccessLogOptions) redactUri(requestUrl *url.URL) string {
+	if len(options.RedactQueries) == 0 || requestUrl.RawQuery == "" {
+		return requestUrl.RequestURI()
+	}
+	redactedUrl := *requestUrl
+	redactedUrl.RawQuery = options.redactValues(requestUrl.Query()).Encode()
+	return redactedUrl.RequestURI()
+}
+
+func (options *AccessLogOptions) redactValues(values url.Values) url.Values {
+	for key := range values {
+		for _, name := range options.RedactQueries {
+			if strings.EqualFold(name, key) {
+				values.Set(key, redacted)
+			}
+		}
+	}
+	return values
+}
+
+func (options *AccessLogOptions) redactHeader(header http.Header) http.Header {
+	result := header.Clone()
+	for _, name := range options.RedactHeaders {
+		if result.Get(name) != "" {
+			result.Set(name, redacted)
+		}
+	}
+	return result
+}
+
+// json替换配置的路径后转换为对象，表单替换配置的参数，multipart不记录，其他的原样记录；超过MaxBodySize截断
+func (options *AccessLogOptions) redactBody(contentType string, body []byte) interface{} {
+	if len(body) == 0 || strings.HasPrefix(contentType, gin.MIMEMultipartPOSTForm) {
+		return nil
+	}
+	content := string(body)
+	if strings.HasPrefix(contentType, gin.MIMEPOSTForm) {
+		if form, err := url.ParseQuery(content); err == nil {
+			content = options.redactValues(form).Encode()
+		}
+	} else if json.Valid(body) {
+		for _, path := range options.RedactJsonPaths {
+			for _, expandedPath := range expandJsonPath(content, path) {
+				if value, err := yaml.Get(content, expandedPath); err == nil && value != nil {
+					if redactedContent, err := yaml.Set(content, expandedPath, redacted); err == nil {
+						content = redactedContent
+					}
+				}
+			}
+		}
+		if options.MaxBodySize <= 0 || len(content) <= options.MaxBodySize {
+			var value interface{}
+			if err := json.Unmarshal([]byte(content), &value); err == nil {
+				return value
+			}
+		}
+	}
+	if options.MaxBodySize > 0 && len(content) > options.MaxBodySize {
+		content = content[:options.MaxBodySize] + "..."
+	}
+	return content
+}
+
+// 展开路径中的[*]为数组的每个下标
+func expandJsonPath(content, path string) []string {
+	index := strings.Index(path, "[*]")
+	if index < 0 {
+		return []string{path}
+	}
+	array, err := yaml.Get(content, path[:index])
+	if err != nil {
+		return nil
+	}
+	items, ok := array.([]interface{})
+	if !ok {
+		return nil
+	}
+	var paths []string
+	for itemIndex := range items {
+		paths = append(paths, expandJsonPath(content, path[:index]+"["+strconv.Itoa(itemIndex)+"]"+path[index+3:])...)
+	}
+	return paths
+}
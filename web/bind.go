@@ -0,0 +1,338 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/isyscore/gole/util"
+	"io/ioutil"
+	"mime/multipart"
+	"reflect"
+	"strings"
+)
+
+// 上传文件的最大内存，超过的部分存放到临时文件
+const maxMultipartMemory = 32 << 20
+
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// Bind 将请求绑定到结构体req并校验，失败时返回参数错误（code为400，data为每个字段的错误信息）并终止请求
+func Bind(c *gin.Context, req interface{}) error {
+	if err := ShouldBind(c, req); err != nil {
+		FailedOfError(c, err)
+		c.Abort()
+		return err
+	}
+	return nil
+}
+
+// ShouldBind 将请求绑定到结构体req并校验，失败时返回*BizError，不写返回值。
+// 字段名和参数名按照首字母小写匹配，不需要添加tag，也可以通过form、json标签指定，请求头需要通过header:"X-Tenant-Id"指定；
+// 来源的优先级：路径参数 > url参数 > 表单参数 > 请求头 > json请求体，上传的文件绑定到*multipart.FileHeader和[]*multipart.FileHeader类型的字段；
+// 匿名嵌入的结构体的字段和外层的字段一样绑定；
+// 校验按照gin的binding标签，比如binding:"required,max=10"，错误信息可以通过message标签指定，错误信息的key为参数名
+func ShouldBind(c *gin.Context, req interface{}) error {
+	reqType := reflect.TypeOf(req)
+	if reqType == nil || reqType.Kind() != reflect.Ptr || reqType.Elem().Kind() != reflect.Struct {
+		return ErrorOf(CodeInternal).WithCause(fmt.Errorf("req of bind must be pointer of struct, but is %v", reqType))
+	}
+	reqType = reqType.Elem()
+
+	bodyMap, err := bodyValues(c)
+	if err != nil {
+		return ErrorOf(CodeBadRequest).WithData(map[string]string{"body": err.Error()}).WithCause(err)
+	}
+
+	// 按照字段名首字母小写的key传给MapToObject，form、json标签指定的参数名不同时也可以绑定
+	values := map[string]interface{}{}
+	fieldErrors := map[string]string{}
+	var files []fileField
+	bindFields(c, reqType, nil, bodyMap, values, &files, fieldErrors)
+	if len(fieldErrors) > 0 {
+		return ErrorOf(CodeBadRequest).WithData(fieldErrors)
+	}
+
+	if err := util.MapToObject(values, req); err != nil {
+		return ErrorOf(CodeInternal).WithCause(err)
+	}
+	reqValue := reflect.ValueOf(req).Elem()
+	for _, file := range files {
+		reqValue.FieldByIndex(file.index).Set(reflect.ValueOf(file.value))
+	}
+
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		validationErrors, ok := err.(validator.ValidationErrors)
+		if !ok {
+			return ErrorOf(CodeBadRequest).WithCause(err)
+		}
+		for _, validationError := range validationErrors {
+			fieldErrors[fieldPath(reqType, validationError)] = validationMessage(reqType, validationError)
+		}
+		return ErrorOf(CodeBadRequest).WithData(fieldErrors).WithCause(err)
+	}
+	return nil
+}
+
+type fileField struct {
+	index []int
+	value interface{}
+}
+
+// 收集结构体字段的值到values中，key为字段名首字母小写；匿名嵌入的结构体的字段和外层的字段一样从参数中绑定
+func bindFields(c *gin.Context, structType reflect.Type, parentIndex []int, bodyMap map[string]interface{}, values map[string]interface{}, files *[]fileField, fieldErrors map[string]string) {
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		if util.IsPrivate(field.Name) {
+			continue
+		}
+		fieldIndex := append(append([]int{}, parentIndex...), index)
+		key := util.ToLowerFirstPrefix(field.Name)
+
+		if embeddedType, ok := embeddedStruct(field); ok {
+			embeddedValues := map[string]interface{}{}
+			bindFields(c, embeddedType, fieldIndex, bodyMap, embeddedValues, files, fieldErrors)
+			if len(embeddedValues) > 0 {
+				values[key] = embeddedValues
+			}
+			continue
+		}
+
+		name := fieldName(field)
+		if field.Type == fileHeaderType || (field.Type.Kind() == reflect.Slice && field.Type.Elem() == fileHeaderType) {
+			if fileHeaders := formFiles(c, name); len(fileHeaders) > 0 {
+				if field.Type == fileHeaderType {
+					*files = append(*files, fileField{index: fieldIndex, value: fileHeaders[0]})
+				} else {
+					*files = append(*files, fileField{index: fieldIndex, value: fileHeaders})
+				}
+			}
+			continue
+		}
+
+		if bodyValue, ok := bodyMap[jsonName(field)]; ok {
+			values[key] = bodyValue
+		}
+		if headerName := field.Tag.Get("header"); headerName != "" {
+			if headerValues := c.Request.Header.Values(headerName); len(headerValues) > 0 {
+				values[key] = stringValues(field.Type, headerValues)
+			}
+		}
+		if postValues, ok := c.Request.PostForm[name]; ok {
+			values[key] = stringValues(field.Type, postValues)
+		}
+		if queryValues, ok := c.Request.URL.Query()[name]; ok {
+			values[key] = stringValues(field.Type, queryValues)
+		}
+		if pathValue, ok := c.Params.Get(name); ok {
+			values[key] = stringValues(field.Type, []string{pathValue})
+		}
+
+		if value, ok := values[key]; ok && !convertible(value, field.Type) {
+			fieldErrors[name] = "类型错误"
+		}
+	}
+}
+
+// 匿名嵌入的结构体，和encoding/json一样字段平铺到外层
+func embeddedStruct(field reflect.StructField) (reflect.Type, bool) {
+	if !field.Anonymous || field.Tag.Get("form") != "" || field.Tag.Get("json") != "" {
+		return nil, false
+	}
+	return field.Type, field.Type.Kind() == reflect.Struct
+}
+
+// json请求体解析为map，表单和multipart解析到PostForm中
+func bodyValues(c *gin.Context) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	switch c.ContentType() {
+	case gin.MIMEMultipartPOSTForm:
+		if err := c.Request.ParseMultipartForm(maxMultipartMemory); err != nil {
+			return nil, err
+		}
+	case gin.MIMEPOSTForm:
+		if err := c.Request.ParseForm(); err != nil {
+			return nil, err
+		}
+	case gin.MIMEJSON:
+		if c.Request.Body == nil {
+			return values, nil
+		}
+		data, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(data))
+		if strings.TrimSpace(string(data)) == "" {
+			return values, nil
+		}
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("请求体不是json对象：%v", err.Error())
+		}
+	}
+	return values, nil
+}
+
+func formFiles(c *gin.Context, name string) []*multipart.FileHeader {
+	if c.Request.MultipartForm == nil {
+		return nil
+	}
+	return c.Request.MultipartForm.File[name]
+}
+
+// 参数名为字段名首字母小写，可以通过form标签指定，其次为json标签；参数错误和校验失败的key都使用参数名
+func fieldName(field reflect.StructField) string {
+	if name := tagName(field, "form"); name != "" {
+		return name
+	}
+	if name := tagName(field, "json"); name != "" {
+		return name
+	}
+	return util.ToLowerFirstPrefix(field.Name)
+}
+
+// json请求体中的参数名，json标签优先
+func jsonName(field reflect.StructField) string {
+	if name := tagName(field, "json"); name != "" {
+		return name
+	}
+	return fieldName(field)
+}
+
+func tagName(field reflect.StructField, tag string) string {
+	if name := strings.Split(field.Tag.Get(tag), ",")[0]; name != "-" {
+		return name
+	}
+	return ""
+}
+
+// 字段为集合时绑定所有的值，否则取第一个
+func stringValues(fieldType reflect.Type, values []string) interface{} {
+	if fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
+		return values
+	}
+	return values[0]
+}
+
+// 基本类型的值是否能够转换为字段的类型
+func convertible(value interface{}, fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if value == nil {
+		return true
+	}
+	if fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
+		valueOfItems := reflect.ValueOf(value)
+		if valueOfItems.Kind() != reflect.Slice {
+			return false
+		}
+		for index := 0; index < valueOfItems.Len(); index++ {
+			if !convertible(valueOfItems.Index(index).Interface(), fieldType.Elem()) {
+				return false
+			}
+		}
+		return true
+	}
+	if !util.IsBaseType(fieldType) || !util.IsBaseType(reflect.TypeOf(value)) {
+		return true
+	}
+	_, err := util.Cast(fieldType.Kind(), util.ToString(value))
+	return err == nil
+}
+
+// 字段路径，比如address.city，每一段为字段的参数名，匿名嵌入的结构体不出现在路径中
+func fieldPath(reqType reflect.Type, validationError validator.FieldError) string {
+	var parts []string
+	structType := reqType
+	for _, part := range strings.Split(validationError.StructNamespace(), ".")[1:] {
+		// 集合的下标，比如items[0]
+		name, suffix := part, ""
+		if index := strings.Index(part, "["); index > 0 {
+			name, suffix = part[:index], part[index:]
+		}
+		for structType.Kind() == reflect.Ptr || structType.Kind() == reflect.Slice || structType.Kind() == reflect.Array || structType.Kind() == reflect.Map {
+			structType = structType.Elem()
+		}
+		field, ok := reflect.StructField{}, false
+		if structType.Kind() == reflect.Struct {
+			field, ok = structType.FieldByName(name)
+		}
+		if !ok {
+			parts = append(parts, util.ToLowerFirstPrefix(name)+suffix)
+			continue
+		}
+		structType = field.Type
+		if _, embedded := embeddedStruct(field); !embedded {
+			parts = append(parts, fieldName(field)+suffix)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// 校验失败的错误信息，优先使用字段的message标签
+func validationMessage(reqType reflect.Type, validationError validator.FieldError) string {
+	if field, ok := nestedField(reqType, strings.Split(validationError.StructNamespace(), ".")[1:]); ok {
+		if message := field.Tag.Get("message"); message != "" {
+			return message
+		}
+	}
+
+	param := validationError.Param()
+	switch validationError.Tag() {
+	case "required":
+		return "不能为空"
+	case "min", "gte":
+		if lengthKind(validationError.Kind()) {
+			return "长度不能小于" + param
+		}
+		return "不能小于" + param
+	case "max", "lte":
+		if lengthKind(validationError.Kind()) {
+			return "长度不能大于" + param
+		}
+		return "不能大于" + param
+	case "gt":
+		return "必须大于" + param
+	case "lt":
+		return "必须小于" + param
+	case "len":
+		return "长度必须为" + param
+	case "oneof":
+		return "必须为[" + param + "]中的一个"
+	case "email":
+		return "邮箱格式不正确"
+	}
+	if param != "" {
+		return "校验失败：" + validationError.Tag() + "=" + param
+	}
+	return "校验失败：" + validationError.Tag()
+}
+
+func lengthKind(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+}
+
+func nestedField(structType reflect.Type, names []string) (reflect.StructField, bool) {
+	var field reflect.StructField
+	for _, name := range names {
+		for structType.Kind() == reflect.Ptr || structType.Kind() == reflect.Slice || structType.Kind() == reflect.Array || structType.Kind() == reflect.Map {
+			structType = structType.Elem()
+		}
+		if structType.Kind() != reflect.Struct {
+			return field, false
+		}
+		// 集合的下标，比如items[0]
+		if index := strings.Index(name, "["); index > 0 {
+			name = name[:index]
+		}
+		var ok bool
+		if field, ok = structType.FieldByName(name); !ok {
+			return field, false
+		}
+		structType = field.Type
+	}
+	return field, true
+}
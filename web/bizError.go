@@ -0,0 +1,135 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// BizError 业务异常，返回为标准的code、message、data格式；code和http.BizError一样为文本，调用方解析后可以直接比较
+type BizError struct {
+	Code    string
+	Message string
+	// 返回的http状态码，默认200
+	HttpStatus int
+	Data       interface{}
+	// 原始的错误，只记录日志不返回
+	Cause error
+}
+
+func (bizError *BizError) Error() string {
+	if bizError.Cause != nil {
+		return fmt.Sprintf("code=%v, message=%v, cause=%v", bizError.Code, bizError.Message, bizError.Cause.Error())
+	}
+	return fmt.Sprintf("code=%v, message=%v", bizError.Code, bizError.Message)
+}
+
+func (bizError *BizError) Unwrap() error {
+	return bizError.Cause
+}
+
+// WithData 返回设置了data的副本
+func (bizError *BizError) WithData(data interface{}) *BizError {
+	result := *bizError
+	result.Data = data
+	return &result
+}
+
+// WithCause 返回设置了原始错误的副本
+func (bizError *BizError) WithCause(cause error) *BizError {
+	result := *bizError
+	result.Cause = cause
+	return &result
+}
+
+// NewBizError 创建业务异常，http状态码为200
+func NewBizError(code string, message string) *BizError {
+	return &BizError{Code: code, Message: message, HttpStatus: http.StatusOK}
+}
+
+// ------------------ 错误码 ------------------
+
+// ErrorCode 注册的错误码
+type ErrorCode struct {
+	Code string
+	// 错误信息，可以包含fmt的占位符，通过ErrorOf的参数填充
+	Message    string
+	HttpStatus int
+}
+
+const (
+	CodeBadRequest   = "400"
+	CodeUnauthorized = "401"
+	CodeForbidden    = "403"
+	CodeNotFound     = "404"
+	CodeInternal     = "500"
+)
+
+var errorCodes = map[string]ErrorCode{}
+var errorCodeLock sync.RWMutex
+
+func init() {
+	RegisterErrorCode(CodeBadRequest, "参数错误", http.StatusBadRequest)
+	RegisterErrorCode(CodeUnauthorized, "未登录", http.StatusUnauthorized)
+	RegisterErrorCode(CodeForbidden, "没有权限", http.StatusForbidden)
+	RegisterErrorCode(CodeNotFound, "资源不存在", http.StatusNotFound)
+	RegisterErrorCode(CodeInternal, "服务内部错误", http.StatusInternalServerError)
+}
+
+// RegisterErrorCode 注册错误码，httpStatus为0时为200；重复注册不同的错误码会panic
+func RegisterErrorCode(code string, message string, httpStatus int) {
+	errorCodeLock.Lock()
+	defer errorCodeLock.Unlock()
+
+	if httpStatus == 0 {
+		httpStatus = http.StatusOK
+	}
+	errorCode := ErrorCode{Code: code, Message: message, HttpStatus: httpStatus}
+	if exist, ok := errorCodes[code]; ok && exist != errorCode {
+		panic(fmt.Sprintf("error code %v already registered: %v", code, exist.Message))
+	}
+	errorCodes[code] = errorCode
+}
+
+// ErrorCodeOf 获取注册的错误码
+func ErrorCodeOf(code string) (ErrorCode, bool) {
+	errorCodeLock.RLock()
+	defer errorCodeLock.RUnlock()
+	errorCode, ok := errorCodes[code]
+	return errorCode, ok
+}
+
+// ErrorCodes 所有注册的错误码，按照code排序，数字的code按照数值排序
+func ErrorCodes() []ErrorCode {
+	errorCodeLock.RLock()
+	defer errorCodeLock.RUnlock()
+
+	var result []ErrorCode
+	for _, errorCode := range errorCodes {
+		result = append(result, errorCode)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		left, leftErr := strconv.ParseInt(result[i].Code, 10, 64)
+		right, rightErr := strconv.ParseInt(result[j].Code, 10, 64)
+		if leftErr == nil && rightErr == nil {
+			return left < right
+		}
+		return result[i].Code < result[j].Code
+	})
+	return result
+}
+
+// ErrorOf 根据注册的错误码创建业务异常，args用于填充错误信息中的占位符；没有注册的错误码http状态码为500
+func ErrorOf(code string, args ...interface{}) *BizError {
+	errorCode, ok := ErrorCodeOf(code)
+	if !ok {
+		return &BizError{Code: code, Message: fmt.Sprintf("unregistered error code %v", code), HttpStatus: http.StatusInternalServerError}
+	}
+	message := errorCode.Message
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	return &BizError{Code: code, Message: message, HttpStatus: errorCode.HttpStatus}
+}
@@ -0,0 +1,80 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"runtime/debug"
+)
+
+// HandlerFunc 返回错误的处理函数，错误由ErrorHandler转换为标准的返回值
+type HandlerFunc func(c *gin.Context) error
+
+// Handle 将返回错误的处理函数转换为gin的处理函数
+func Handle(handler HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := handler(c); err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
+
+// ErrorHandler 统一的异常处理：处理函数返回的错误（c.Error）和panic转换为标准的code、message、data返回值，
+// BizError按照其中的http状态码、code、message和data返回，其他的错误和panic返回500且不返回错误信息，panic记录堆栈
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				// 客户端断开的按照http的约定继续抛出
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+				bizError, ok := recovered.(*BizError)
+				if !ok {
+					getAccessLogger().WithContext(c).WithField("stack", string(debug.Stack())).Errorf("%v %v panic: %v", c.Request.Method, c.Request.URL.Path, recovered)
+					bizError = ErrorOf(CodeInternal).WithCause(fmt.Errorf("panic: %v", recovered))
+				}
+				// 响应已经开始写出时不能再返回错误，只记录日志
+				if c.Writer.Written() {
+					if ok {
+						getAccessLogger().WithContext(c).Errorf("%v %v panic after response written: %v", c.Request.Method, c.Request.URL.Path, bizError.Error())
+					}
+				} else {
+					FailedOfError(c, bizError)
+				}
+				c.Abort()
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+		err := c.Errors.Last().Err
+		var bizError *BizError
+		if !errors.As(err, &bizError) {
+			bizError = ErrorOf(CodeInternal).WithCause(err)
+		}
+		if bizError.HttpStatus >= http.StatusInternalServerError {
+			getAccessLogger().WithContext(c).Errorf("%v %v failed: %v", c.Request.Method, c.Request.URL.Path, bizError.Error())
+		}
+		if !c.Writer.Written() {
+			FailedOfError(c, bizError)
+		}
+	}
+}
+
+// FailedOfError 返回错误：BizError按照其中的信息返回，其他的错误返回500
+func FailedOfError(ctx *gin.Context, err error) {
+	var bizError *BizError
+	if !errors.As(err, &bizError) {
+		bizError = ErrorOf(CodeInternal).WithCause(err)
+	}
+	ctx.JSON(bizError.HttpStatus, map[string]interface{}{
+		"code":    bizError.Code,
+		"message": bizError.Message,
+		"data":    bizError.Data,
+	})
+}
@@ -0,0 +1,226 @@
+package web
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/isyscore/gole/config"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 分页的参数名
+const (
+	ParamPage   = "page"
+	ParamSize   = "size"
+	ParamSort   = "sort"
+	ParamCursor = "cursor"
+)
+
+var pageConfig = config.BasePage{DefaultSize: 20, MaxSize: 1000}
+var pageConfigLock sync.RWMutex
+
+func init() {
+	loadPageConfig(nil)
+	config.AddChangeListener("base.server.page", loadPageConfig)
+}
+
+// 排序的字段只允许字母、数字、下划线和点，避免拼接到sql中时注入
+var sortFieldPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// SortOrder 排序
+type SortOrder struct {
+	Field string
+	Desc  bool
+}
+
+// PageRequest 分页请求，page从1开始
+type PageRequest struct {
+	Page int
+	Size int
+	Sort []SortOrder
+}
+
+// Offset 跳过的条数
+func (req *PageRequest) Offset() int {
+	return (req.Page - 1) * req.Size
+}
+
+// Limit 查询的条数
+func (req *PageRequest) Limit() int {
+	return req.Size
+}
+
+// CursorRequest 游标分页请求，第一页的cursor为空
+type CursorRequest struct {
+	Cursor string
+	Size   int
+}
+
+// PageRequestOf 解析url参数中的page、size和sort，page默认1，size默认和最大值通过base.server.page配置；
+// sort为逗号分隔或者多个参数，比如：sort=name,-createTime 或者 sort=name:asc&sort=createTime:desc
+func PageRequestOf(c *gin.Context) (*PageRequest, error) {
+	fieldErrors := map[string]string{}
+	page := intParam(c, ParamPage, 1, fieldErrors)
+	size := sizeParam(c, fieldErrors)
+
+	var sortOrders []SortOrder
+	for _, sortValue := range c.QueryArray(ParamSort) {
+		for _, item := range strings.Split(sortValue, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			sortOrder, ok := sortOrderOf(item)
+			if !ok {
+				fieldErrors[ParamSort] = "排序格式不正确：" + item
+				break
+			}
+			sortOrders = append(sortOrders, sortOrder)
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, ErrorOf(CodeBadRequest).WithData(fieldErrors)
+	}
+	return &PageRequest{Page: page, Size: size, Sort: sortOrders}, nil
+}
+
+// CursorRequestOf 解析url参数中的cursor和size，size默认和最大值通过base.server.page配置
+func CursorRequestOf(c *gin.Context) (*CursorRequest, error) {
+	fieldErrors := map[string]string{}
+	size := sizeParam(c, fieldErrors)
+	if len(fieldErrors) > 0 {
+		return nil, ErrorOf(CodeBadRequest).WithData(fieldErrors)
+	}
+	return &CursorRequest{Cursor: c.Query(ParamCursor), Size: size}, nil
+}
+
+// SuccessOfPage 返回分页数据：data为{"items":[],"total":0,"page":1,"size":20,"pages":0}
+func SuccessOfPage(ctx *gin.Context, items interface{}, total int64, req *PageRequest) {
+	pages := int64(0)
+	if req.Size > 0 {
+		pages = (total + int64(req.Size) - 1) / int64(req.Size)
+	}
+	SuccessOfStandard(ctx, map[string]interface{}{
+		"items": itemsOf(items),
+		"total": total,
+		"page":  req.Page,
+		"size":  req.Size,
+		"pages": pages,
+	})
+}
+
+// SuccessOfCursor 返回游标分页数据：data为{"items":[],"nextCursor":"","hasMore":false}，nextCursor为空表示没有下一页
+func SuccessOfCursor(ctx *gin.Context, items interface{}, nextCursor string) {
+	SuccessOfStandard(ctx, map[string]interface{}{
+		"items":      itemsOf(items),
+		"nextCursor": nextCursor,
+		"hasMore":    nextCursor != "",
+	})
+}
+
+// EncodeCursor 将游标的值（比如最后一条的id和排序字段）编码为不透明的字符串
+func EncodeCursor(value interface{}) (string, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(content), nil
+}
+
+// DecodeCursor 解码EncodeCursor生成的游标，格式不正确时返回参数错误
+func DecodeCursor(cursor string, target interface{}) error {
+	content, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(content, target)
+	}
+	if err != nil {
+		return ErrorOf(CodeBadRequest).WithData(map[string]string{ParamCursor: "游标格式不正确"}).WithCause(err)
+	}
+	return nil
+}
+
+func intParam(c *gin.Context, name string, defaultValue int, fieldErrors map[string]string) int {
+	value := c.Query(name)
+	if value == "" {
+		return defaultValue
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil || result < 1 {
+		fieldErrors[name] = "必须为大于0的整数"
+		return defaultValue
+	}
+	return result
+}
+
+// 超过最大值时按照最大值查询
+func sizeParam(c *gin.Context, fieldErrors map[string]string) int {
+	pageSize := getPageConfig()
+	size := intParam(c, ParamSize, pageSize.DefaultSize, fieldErrors)
+	if pageSize.MaxSize > 0 && size > pageSize.MaxSize {
+		return pageSize.MaxSize
+	}
+	return size
+}
+
+// 读取base.server.page的配置，运行时修改立即生效；没有配置的按照默认值，maxSize配置为0时不限制
+func loadPageConfig([]string) {
+	pageSize := config.BasePage{DefaultSize: 20, MaxSize: 1000}
+	if config.GetValue("base.server.page") != nil {
+		if err := config.GetValueObject("base.server.page", &pageSize); err != nil {
+			getAccessLogger().Errorf("read base.server.page config err, %v", err.Error())
+			return
+		}
+		if config.GetValue("base.server.page.defaultSize") == nil || pageSize.DefaultSize <= 0 {
+			pageSize.DefaultSize = 20
+		}
+		if config.GetValue("base.server.page.maxSize") == nil {
+			pageSize.MaxSize = 1000
+		}
+	}
+	config.BaseCfg.Server.Page = pageSize
+
+	pageConfigLock.Lock()
+	defer pageConfigLock.Unlock()
+	pageConfig = pageSize
+}
+
+func getPageConfig() config.BasePage {
+	pageConfigLock.RLock()
+	defer pageConfigLock.RUnlock()
+	return pageConfig
+}
+
+// 排序项：name、-name、name:asc、name:desc
+func sortOrderOf(item string) (SortOrder, bool) {
+	sortOrder := SortOrder{Field: item}
+	if strings.HasPrefix(item, "-") {
+		sortOrder = SortOrder{Field: item[1:], Desc: true}
+	} else if index := strings.LastIndex(item, ":"); index > 0 {
+		switch strings.ToLower(item[index+1:]) {
+		case "asc":
+		case "desc":
+			sortOrder.Desc = true
+		default:
+			return sortOrder, false
+		}
+		sortOrder.Field = item[:index]
+	}
+	return sortOrder, sortFieldPattern.MatchString(sortOrder.Field)
+}
+
+// nil的集合返回为[]而不是null
+func itemsOf(items interface{}) interface{} {
+	if items == nil {
+		return []interface{}{}
+	}
+	value := reflect.ValueOf(items)
+	if value.Kind() == reflect.Slice && value.IsNil() {
+		return []interface{}{}
+	}
+	return items
+}
@@ -6,11 +6,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/isyscore/gole/config"
 	http2 "github.com/isyscore/gole/http"
-	"github.com/isyscore/gole/log"
 	"github.com/isyscore/gole/util"
 	"io/ioutil"
 	"net/http"
-	"strings"
 	"time"
 )
 
@@ -24,18 +22,16 @@ func (w bodyLogWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
-// ResponseHandler 日志记录到文件
+// ResponseHandler 访问日志记录到文件：异常的请求都记录，base.server.accessLog.enable开启后按照采样比例记录正常的请求
 func ResponseHandler(exceptCode ...int) gin.HandlerFunc {
-	//实例化
-	logger := log.GetLogger("isc-config-service")
-
 	return func(c *gin.Context) {
 		// 开始时间
 		startTime := time.Now()
+		options := getAccessLogOptions()
 
 		data, err := ioutil.ReadAll(c.Request.Body)
 		if err != nil {
-			logger.Errorf("read request body failed,err = %s.", err)
+			getAccessLogger().WithContext(c).Errorf("read request body failed,err = %s.", err)
 			return
 		}
 		c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(data))
@@ -49,30 +45,16 @@ func ResponseHandler(exceptCode ...int) gin.HandlerFunc {
 		// 状态码
 		statusCode := c.Writer.Status()
 
-		var body interface{}
-		bodyStr := string(data)
-		if "" != bodyStr {
-			if strings.HasPrefix(bodyStr, "{") && strings.HasSuffix(bodyStr, "}") {
-				bodys := map[string]interface{}{}
-				_ = util.StrToObject(bodyStr, &bodys)
-				body = bodys
-			} else if strings.HasPrefix(bodyStr, "[") && strings.HasSuffix(bodyStr, "]") {
-				var bodys []interface{}
-				_ = util.StrToObject(bodyStr, &bodys)
-				body = bodys
-			}
-		}
-
 		request := Request{
 			Method:     c.Request.Method,
-			Uri:        c.Request.RequestURI,
+			Uri:        options.redactUri(c.Request.URL),
 			Ip:         c.ClientIP(),
 			Parameters: c.Params,
-			Body:       body,
+			Body:       options.redactBody(c.ContentType(), data),
 		}
 
-		if config.GetValueBool("gole.show.head") {
-			request.Headers = c.Request.Header
+		if options.ShowHead || config.GetValueBool("gole.show.head") {
+			request.Headers = options.redactHeader(c.Request.Header)
 		}
 
 		message := ErrorMessage{
@@ -81,29 +63,42 @@ func ResponseHandler(exceptCode ...int) gin.HandlerFunc {
 			Cost:       time.Now().Sub(startTime).String(),
 		}
 
-		if statusCode != 200 {
-			for _, code := range exceptCode {
-				if code == statusCode {
-					return
-				}
-			}
-			logger.WithField("result", util.ObjectToJson(message)).Error("请求异常")
-		} else {
-			var response http2.StandardResponse
-			err := json.Unmarshal([]byte(blw.body.String()), &response)
-			if err != nil {
-				return
-			} else {
-				if response.Code == nil {
-					return
-				}
-				if response.Code != 0 && response.Code != "0" && response.Code != 200 && response.Code != "200" && response.Code != "success" {
-					message.Response = response
-					logger.WithField("result", util.ObjectToJson(message)).Error("请求异常")
-				}
+		if failed(statusCode, blw.body.Bytes(), &message, exceptCode) {
+			message.ResponseBody = options.redactBody(blw.Header().Get("Content-Type"), blw.body.Bytes())
+			getAccessLogger().WithContext(c).WithField("result", util.ObjectToJson(message)).Error("请求异常")
+		} else if options.sampled() {
+			message.ResponseBody = options.redactBody(blw.Header().Get("Content-Type"), blw.body.Bytes())
+			getAccessLogger().WithContext(c).WithField("result", util.ObjectToJson(message)).Info("请求")
+		}
+	}
+}
+
+// 状态码不为200且不在排除的状态码中，或者标准返回值的code不为成功时为异常的请求
+func failed(statusCode int, body []byte, message *ErrorMessage, exceptCode []int) bool {
+	if statusCode != 200 {
+		for _, code := range exceptCode {
+			if code == statusCode {
+				return false
 			}
 		}
+		return true
+	}
+
+	var response http2.StandardResponse
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&response); err != nil || response.Code == nil {
+		return false
+	}
+	// 数字和字符串的code都按照文本和成功的code比较
+	code := util.ToString(response.Code)
+	for _, successCode := range http2.SuccessCodes() {
+		if code == successCode {
+			return false
+		}
 	}
+	message.Response = response
+	return true
 }
 
 type Request struct {
@@ -116,10 +111,12 @@ type Request struct {
 }
 
 type ErrorMessage struct {
-	Request    Request
-	Response   http2.StandardResponse
-	Cost       string
-	StatusCode int
+	Request  Request
+	Response http2.StandardResponse
+	// 记录的响应体，json为对象，超过长度截断为字符
+	ResponseBody interface{}
+	Cost         string
+	StatusCode   int
 }
 
 func Success(ctx *gin.Context, object interface{}) {
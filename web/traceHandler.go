@@ -0,0 +1,48 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/isyscore/gole/trace"
+	"net/http"
+)
+
+// TraceHandler 链路追踪：从请求头traceparent、tracestate中提取上游的链路，没有则新建，并创建server span；
+// 处理中通过http调用时传入gin.Context，会自动注入链路；日志通过logger.WithContext(c)记录traceId和spanId
+func TraceHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parent, _ := trace.Extract(c.Request.Header)
+		ctx, span := trace.StartSpanWithParent(c.Request.Context(), c.Request.Method+" "+routeOf(c), trace.SpanKindServer, parent)
+		span.SetAttribute("http.method", c.Request.Method)
+		span.SetAttribute("http.target", c.Request.URL.RequestURI())
+		span.SetAttribute("http.client_ip", c.ClientIP())
+		c.Request = c.Request.WithContext(ctx)
+
+		defer func() {
+			if err := recover(); err != nil {
+				span.SetError(fmt.Errorf("panic: %v", err))
+				span.End()
+				panic(err)
+			}
+		}()
+		c.Next()
+
+		statusCode := c.Writer.Status()
+		span.SetAttribute("http.status_code", statusCode)
+		if len(c.Errors) > 0 {
+			span.SetError(c.Errors.Last())
+		} else if statusCode >= http.StatusInternalServerError {
+			span.SetError(errors.New(http.StatusText(statusCode)))
+		}
+		span.End()
+	}
+}
+
+// 路由的模板，没有匹配的路由时为请求路径
+func routeOf(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return c.Request.URL.Path
+}
@@ -0,0 +1,228 @@
+package yaml
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// properties文件的解析和生成，格式遵循java.util.Properties：
+//  - 注释：行首（忽略前导空白）为'#'或'!'
+//  - 分隔符：'='、':'或空白，分隔符前后的空白忽略
+//  - 续行：行尾为奇数个'\'，下一行的前导空白忽略
+//  - 转义：\t \n \r \f \uXXXX，其他的'\x'表示x本身
+// 生成时非ascii字符和控制字符转义为\uXXXX，超过U+FFFF的字符转义为代理对，java读取时不受编码影响
+
+// 读取逻辑行：合并续行，去掉空行和注释行，每行已去掉前导空白
+func readLogicalLines(content string) []string {
+	naturalLines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", NewLine), "\r", NewLine), NewLine)
+
+	var logicalLines []string
+	var appender strings.Builder
+	var continuation = false
+	for _, line := range naturalLines {
+		line = strings.TrimLeft(line, " \t\f")
+		if !continuation {
+			if "" == line || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+				continue
+			}
+		}
+
+		if endWithContinuation(line) {
+			appender.WriteString(line[:len(line)-1])
+			continuation = true
+			continue
+		}
+
+		appender.WriteString(line)
+		logicalLines = append(logicalLines, appender.String())
+		appender.Reset()
+		continuation = false
+	}
+
+	if appender.Len() > 0 {
+		logicalLines = append(logicalLines, appender.String())
+	}
+	return logicalLines
+}
+
+// 将逻辑行拆分为key和value，此时的key和value还未进行转义处理
+func splitLogicalLine(line string) (string, string) {
+	keyEnd := len(line)
+	valueStart := len(line)
+	hasSeparator := false
+	for index := 0; index < len(line); index++ {
+		c := line[index]
+		if c == '\\' {
+			index++
+			continue
+		}
+		if c == '=' || c == ':' {
+			keyEnd = index
+			valueStart = index + 1
+			hasSeparator = true
+			break
+		}
+		if c == ' ' || c == '\t' || c == '\f' {
+			keyEnd = index
+			valueStart = index + 1
+			break
+		}
+	}
+
+	// 空白作为分隔符时，其后可以再跟一个'='或':'
+	for valueStart < len(line) {
+		c := line[valueStart]
+		if c == ' ' || c == '\t' || c == '\f' {
+			valueStart++
+			continue
+		}
+		if !hasSeparator && (c == '=' || c == ':') {
+			valueStart++
+			hasSeparator = true
+			continue
+		}
+		break
+	}
+	return line[:keyEnd], line[valueStart:]
+}
+
+// 处理转义字符
+func unescapeProperties(content string) (string, error) {
+	if !strings.Contains(content, "\\") {
+		return content, nil
+	}
+
+	var builder strings.Builder
+	var units []uint16
+	flushUnits := func() {
+		if len(units) > 0 {
+			builder.WriteString(string(utf16.Decode(units)))
+			units = units[:0]
+		}
+	}
+
+	for index := 0; index < len(content); index++ {
+		c := content[index]
+		if c != '\\' {
+			flushUnits()
+			builder.WriteByte(c)
+			continue
+		}
+
+		index++
+		if index >= len(content) {
+			break
+		}
+		c = content[index]
+		if c == 'u' {
+			if index+4 >= len(content) {
+				return "", &ConvertError{errMsg: "malformed \\uxxxx encoding: " + content}
+			}
+			code, err := strconv.ParseUint(content[index+1:index+5], 16, 16)
+			if err != nil {
+				return "", &ConvertError{errMsg: "malformed \\uxxxx encoding: " + content}
+			}
+			// 代理对需要两个\uXXXX一起解码
+			units = append(units, uint16(code))
+			index += 4
+			continue
+		}
+
+		flushUnits()
+		switch c {
+		case 't':
+			builder.WriteByte('\t')
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'f':
+			builder.WriteByte('\f')
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	flushUnits()
+	return builder.String(), nil
+}
+
+// 解析properties的内容为key-value的列表，key和value都已经转义
+func parseProperties(content string) ([]StringPair, error) {
+	var pairs []StringPair
+	for _, line := range readLogicalLines(content) {
+		rawKey, rawValue := splitLogicalLine(line)
+		key, err := unescapeProperties(rawKey)
+		if err != nil {
+			return nil, err
+		}
+		value, err := unescapeProperties(rawValue)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, StringPair{Left: key, Right: value})
+	}
+	return pairs, nil
+}
+
+// 生成properties的key，需要对空白和分隔符进行转义
+func escapePropertiesKey(key string) string {
+	return escapeProperties(key, true)
+}
+
+// 生成properties的value，只有前导空白需要转义
+func escapePropertiesValue(value string) string {
+	return escapeProperties(value, false)
+}
+
+func escapeProperties(content string, isKey bool) string {
+	var builder strings.Builder
+	var leading = true
+	for _, c := range content {
+		if c != ' ' {
+			leading = false
+		}
+		switch c {
+		case '\\':
+			builder.WriteString("\\\\")
+		case '\t':
+			builder.WriteString("\\t")
+		case '\n':
+			builder.WriteString("\\n")
+		case '\r':
+			builder.WriteString("\\r")
+		case '\f':
+			builder.WriteString("\\f")
+		case ' ':
+			if isKey || leading {
+				builder.WriteString("\\ ")
+			} else {
+				builder.WriteRune(c)
+			}
+		case '=', ':', '#', '!':
+			if isKey {
+				builder.WriteByte('\\')
+			}
+			builder.WriteRune(c)
+		default:
+			// java按照ISO-8859-1读取，非ASCII的字符需要转义，超过U+FFFF的转义为代理对
+			if c > 0xffff {
+				high, low := utf16.EncodeRune(c)
+				builder.WriteString("\\u" + leftPadHex(int64(high)) + "\\u" + leftPadHex(int64(low)))
+			} else if c < 0x20 || c > 0x7e {
+				builder.WriteString("\\u" + leftPadHex(int64(c)))
+			} else {
+				builder.WriteRune(c)
+			}
+		}
+	}
+	return builder.String()
+}
+
+func leftPadHex(value int64) string {
+	hex := strings.ToUpper(strconv.FormatInt(value, 16))
+	for len(hex) < 4 {
+		hex = "0" + hex
+	}
+	return hex
+}
@@ -0,0 +1,456 @@
+package yaml
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/isyscore/gole/util"
+	"gopkg.in/yaml.v2"
+)
+
+/**
+ * 对象 <---> properties
+ * 字段名的优先级：prop > yaml > json，都没有则为首字母小写的字段名
+ *  - tag为"-"：忽略该字段
+ *  - omitempty：零值不输出
+ *  - inline或者匿名的结构体：字段平铺到上一层
+ *  - time.Duration：1m30s 这种格式
+ *  - time.Time：RFC3339格式，读取时也支持 2006-01-02 15:04:05 和 2006-01-02
+ */
+
+var durationType = reflect.TypeOf(time.Duration(0))
+var timeType = reflect.TypeOf(time.Time{})
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.000", "2006-01-02 15:04:05", "2006-01-02"}
+
+type fieldInfo struct {
+	name      string
+	omitEmpty bool
+	inline    bool
+}
+
+// ObjectToProperties 对象转换为properties，按照字段的定义顺序输出
+func ObjectToProperties(object interface{}) (string, error) {
+	node, err := encodeObjectNode(reflect.ValueOf(object))
+	if err != nil {
+		return "", err
+	}
+
+	var propertyStrList []string
+	propertyStrList = nodeToProperties(propertyStrList, node, "")
+
+	var builder strings.Builder
+	for _, propertyStr := range propertyStrList {
+		builder.WriteString(propertyStr + NewLine)
+	}
+	return builder.String(), nil
+}
+
+// PropertiesToObject properties转换为对象，targetPtrObj需要为指针
+func PropertiesToObject(contentOfProperties string, targetPtrObj interface{}) error {
+	targetValue := reflect.ValueOf(targetPtrObj)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() {
+		return &ConvertError{errMsg: "targetPtrObj must be a non-nil pointer"}
+	}
+
+	pairs, err := parseProperties(contentOfProperties)
+	if err != nil {
+		return err
+	}
+
+	var document interface{}
+	for _, pair := range pairs {
+		tokens, err := parsePath(pair.Left)
+		if err != nil {
+			return err
+		}
+		document = putPropertyNode(document, tokens, pair.Right)
+	}
+	return decodeObjectNode(document, targetValue.Elem(), "")
+}
+
+// 解析字段的名字和选项
+func getFieldInfo(field reflect.StructField) (fieldInfo, bool) {
+	var tagValue string
+	for _, tagName := range []string{"prop", "yaml", "json"} {
+		if value, exist := field.Tag.Lookup(tagName); exist {
+			tagValue = value
+			break
+		}
+	}
+	if tagValue == "-" {
+		return fieldInfo{}, false
+	}
+
+	info := fieldInfo{}
+	words := strings.Split(tagValue, ",")
+	info.name = words[0]
+	for _, option := range words[1:] {
+		switch strings.TrimSpace(option) {
+		case "omitempty":
+			info.omitEmpty = true
+		case "inline", "squash":
+			info.inline = true
+		}
+	}
+
+	fieldType := field.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if field.Anonymous && info.name == "" && fieldType.Kind() == reflect.Struct {
+		info.inline = true
+	}
+
+	// 私有字段不处理，匿名结构体的公有字段可以平铺
+	if field.PkgPath != "" && !info.inline {
+		return fieldInfo{}, false
+	}
+	if info.name == "" {
+		info.name = util.ToLowerFirstPrefix(field.Name)
+	}
+	return info, true
+}
+
+// 对象转换为节点：对象为yaml.MapSlice，数组为[]interface{}
+func encodeObjectNode(value reflect.Value) (interface{}, error) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return nil, nil
+	}
+
+	valueType := value.Type()
+	if valueType == durationType {
+		return time.Duration(value.Int()).String(), nil
+	}
+	if valueType == timeType {
+		return value.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	}
+	if valueType.Implements(textMarshalerType) {
+		text, err := value.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		result := yaml.MapSlice{}
+		if err := encodeStructFields(value, &result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	case reflect.Map:
+		var keys []string
+		values := map[string]reflect.Value{}
+		for mapR := value.MapRange(); mapR.Next(); {
+			key := fmt.Sprintf("%v", mapR.Key().Interface())
+			keys = append(keys, key)
+			values[key] = mapR.Value()
+		}
+		sort.Strings(keys)
+
+		result := yaml.MapSlice{}
+		for _, key := range keys {
+			node, err := encodeObjectNode(values[key])
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, yaml.MapItem{Key: key, Value: node})
+		}
+		return result, nil
+	case reflect.Slice, reflect.Array:
+		if valueType.Elem().Kind() == reflect.Uint8 {
+			// 不可寻址的[N]byte不能调用Bytes，复制到切片中
+			bytes := make([]byte, value.Len())
+			reflect.Copy(reflect.ValueOf(bytes), value)
+			return string(bytes), nil
+		}
+		result := make([]interface{}, 0, value.Len())
+		for index := 0; index < value.Len(); index++ {
+			node, err := encodeObjectNode(value.Index(index))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, node)
+		}
+		return result, nil
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return nil, &ConvertError{errMsg: "not support type: " + valueType.String()}
+	}
+	return value.Interface(), nil
+}
+
+func encodeStructFields(value reflect.Value, result *yaml.MapSlice) error {
+	valueType := value.Type()
+	for index := 0; index < valueType.NumField(); index++ {
+		field := valueType.Field(index)
+		info, ok := getFieldInfo(field)
+		if !ok {
+			continue
+		}
+
+		fieldValue := value.Field(index)
+		if info.omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+		if info.inline {
+			for fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					break
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			if fieldValue.Kind() == reflect.Struct {
+				if err := encodeStructFields(fieldValue, result); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		node, err := encodeObjectNode(fieldValue)
+		if err != nil {
+			return err
+		}
+		*result = append(*result, yaml.MapItem{Key: info.name, Value: node})
+	}
+	return nil
+}
+
+func nodeToProperties(propertyStrList []string, node interface{}, prefix string) []string {
+	switch data := node.(type) {
+	case nil:
+		return propertyStrList
+	case yaml.MapSlice:
+		for _, item := range data {
+			propertyStrList = nodeToProperties(propertyStrList, item.Value, prefixWithDOT(prefix)+fmt.Sprintf("%v", item.Key))
+		}
+		return propertyStrList
+	case []interface{}:
+		for index, item := range data {
+			propertyStrList = nodeToProperties(propertyStrList, item, prefix+"["+strconv.Itoa(index)+"]")
+		}
+		return propertyStrList
+	}
+	return append(propertyStrList, escapePropertiesKey(prefix)+SignEqual+escapePropertiesValue(fmt.Sprintf("%v", node)))
+}
+
+// 将properties的值放入节点中，数组下标可以乱序
+func putPropertyNode(node interface{}, tokens []pathToken, value string) interface{} {
+	if len(tokens) == 0 {
+		return value
+	}
+
+	token := tokens[0]
+	if token.index >= 0 {
+		data, _ := node.([]interface{})
+		for len(data) <= token.index {
+			data = append(data, nil)
+		}
+		data[token.index] = putPropertyNode(data[token.index], tokens[1:], value)
+		return data
+	}
+
+	data, _ := node.(yaml.MapSlice)
+	itemIndex := mapSliceIndex(data, token.name)
+	if itemIndex < 0 {
+		return append(data, yaml.MapItem{Key: token.name, Value: putPropertyNode(nil, tokens[1:], value)})
+	}
+	data[itemIndex].Value = putPropertyNode(data[itemIndex].Value, tokens[1:], value)
+	return data
+}
+
+// 将节点的值设置到对象中，path用于错误提示
+func decodeObjectNode(node interface{}, target reflect.Value, path string) error {
+	if node == nil {
+		return nil
+	}
+
+	if target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		return decodeObjectNode(node, target.Elem(), path)
+	}
+
+	targetType := target.Type()
+	text, isText := node.(string)
+	if targetType == durationType && isText {
+		duration, err := time.ParseDuration(strings.TrimSpace(text))
+		if err != nil {
+			nanos, numErr := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+			if numErr != nil {
+				return decodeError(path, text, targetType, err)
+			}
+			duration = time.Duration(nanos)
+		}
+		target.SetInt(int64(duration))
+		return nil
+	}
+	if targetType == timeType && isText {
+		for _, layout := range timeLayouts {
+			if value, err := time.ParseInLocation(layout, strings.TrimSpace(text), time.Local); err == nil {
+				target.Set(reflect.ValueOf(value))
+				return nil
+			}
+		}
+		return decodeError(path, text, targetType, nil)
+	}
+	if isText && reflect.PtrTo(targetType).Implements(textUnmarshalerType) {
+		if err := target.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+			return decodeError(path, text, targetType, err)
+		}
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Interface:
+		target.Set(reflect.ValueOf(toPlainValue(node)))
+		return nil
+	case reflect.Struct:
+		data, ok := node.(yaml.MapSlice)
+		if !ok {
+			return decodeError(path, node, targetType, nil)
+		}
+		return decodeStructFields(data, target, path)
+	case reflect.Map:
+		data, ok := node.(yaml.MapSlice)
+		if !ok {
+			return decodeError(path, node, targetType, nil)
+		}
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(targetType))
+		}
+		for _, item := range data {
+			key := reflect.New(targetType.Key()).Elem()
+			if err := decodeObjectNode(fmt.Sprintf("%v", item.Key), key, path); err != nil {
+				return err
+			}
+			value := reflect.New(targetType.Elem()).Elem()
+			if err := decodeObjectNode(item.Value, value, prefixWithDOT(path)+fmt.Sprintf("%v", item.Key)); err != nil {
+				return err
+			}
+			target.SetMapIndex(key, value)
+		}
+		return nil
+	case reflect.Slice, reflect.Array:
+		if targetType.Elem().Kind() == reflect.Uint8 && isText {
+			if target.Kind() == reflect.Slice {
+				target.SetBytes([]byte(text))
+			} else {
+				reflect.Copy(target, reflect.ValueOf([]byte(text)))
+			}
+			return nil
+		}
+
+		var items []interface{}
+		switch data := node.(type) {
+		case []interface{}:
+			items = data
+		case string:
+			// 支持逗号分隔的写法：a.b=x,y,z
+			for _, item := range strings.Split(data, ",") {
+				items = append(items, strings.TrimSpace(item))
+			}
+		default:
+			return decodeError(path, node, targetType, nil)
+		}
+
+		if target.Kind() == reflect.Slice {
+			target.Set(reflect.MakeSlice(targetType, len(items), len(items)))
+		}
+		for index := 0; index < len(items) && index < target.Len(); index++ {
+			if err := decodeObjectNode(items[index], target.Index(index), path+"["+strconv.Itoa(index)+"]"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !isText {
+		return decodeError(path, node, targetType, nil)
+	}
+	if target.Kind() == reflect.String {
+		target.SetString(text)
+		return nil
+	}
+	value, err := util.Cast(target.Kind(), strings.TrimSpace(text))
+	if err != nil {
+		return decodeError(path, text, targetType, err)
+	}
+	if value == nil {
+		return nil
+	}
+	target.Set(reflect.ValueOf(value).Convert(targetType))
+	return nil
+}
+
+func decodeStructFields(data yaml.MapSlice, target reflect.Value, path string) error {
+	targetType := target.Type()
+	for index := 0; index < targetType.NumField(); index++ {
+		field := targetType.Field(index)
+		info, ok := getFieldInfo(field)
+		if !ok {
+			continue
+		}
+
+		fieldValue := target.Field(index)
+		if info.inline {
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					if !fieldValue.CanSet() {
+						continue
+					}
+					fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			if fieldValue.Kind() == reflect.Struct {
+				if err := decodeStructFields(data, fieldValue, path); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		itemIndex := mapSliceIndex(data, info.name)
+		if itemIndex < 0 {
+			// key不区分大小写
+			for innerIndex, item := range data {
+				if strings.EqualFold(fmt.Sprintf("%v", item.Key), info.name) {
+					itemIndex = innerIndex
+					break
+				}
+			}
+		}
+		if itemIndex < 0 {
+			continue
+		}
+		if err := decodeObjectNode(data[itemIndex].Value, fieldValue, prefixWithDOT(path)+info.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeError(path string, value interface{}, targetType reflect.Type, err error) error {
+	errMsg := fmt.Sprintf("can not convert value %v of key %v to type %v", value, path, targetType)
+	if err != nil {
+		errMsg += ", err: " + err.Error()
+	}
+	return &ConvertError{errMsg: errMsg}
+}
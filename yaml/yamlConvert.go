@@ -0,0 +1,180 @@
+package yaml
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Format 文本内容的格式，与TypeEnum一致
+type Format = TypeEnum
+
+// Detect 探测内容的格式：JSON、YAML、PROPERTIES，都不是则返回STRING
+// 只做逐行的词法判断，不进行完整的转换，相同的内容结果总是一致
+func Detect(content string) Format {
+	content = strings.TrimSpace(content)
+	if "" == content {
+		return STRING
+	}
+
+	if (strings.HasPrefix(content, "{") || strings.HasPrefix(content, "[")) && json.Valid([]byte(content)) {
+		return JSON
+	}
+
+	var firstFormat = STRING
+	var yamlCount, propertiesCount int
+	var continuation = false
+	for _, line := range strings.Split(content, NewLine) {
+		line = strings.TrimRight(line, "\r")
+		// properties的续行，属于上一行的value
+		if continuation {
+			continuation = endWithContinuation(line)
+			continue
+		}
+
+		trimLine := strings.TrimSpace(line)
+		if "" == trimLine || strings.HasPrefix(trimLine, "#") || strings.HasPrefix(trimLine, "!") {
+			continue
+		}
+
+		lineFormat := detectLine(line)
+		if lineFormat == STRING {
+			return STRING
+		}
+		if lineFormat == PROPERTIES {
+			propertiesCount++
+			continuation = endWithContinuation(line)
+		} else {
+			yamlCount++
+		}
+		if firstFormat == STRING {
+			firstFormat = lineFormat
+		}
+	}
+
+	if yamlCount == 0 && propertiesCount == 0 {
+		return STRING
+	}
+	if yamlCount == 0 {
+		return PROPERTIES
+	}
+	if propertiesCount == 0 {
+		return YAML
+	}
+	return firstFormat
+}
+
+// 单行的格式判断
+func detectLine(line string) Format {
+	// 缩进、数组、文档分隔符只有yaml才有
+	if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+		return YAML
+	}
+	if line == "-" || strings.HasPrefix(line, ArrayBlanks) || strings.HasPrefix(line, "---") {
+		return YAML
+	}
+
+	equalIndex := strings.Index(line, SignEqual)
+	semicolonIndex := strings.Index(line, SignSemicolon)
+	if equalIndex == -1 && semicolonIndex == -1 {
+		return STRING
+	}
+	if semicolonIndex == -1 || (equalIndex != -1 && equalIndex < semicolonIndex) {
+		return PROPERTIES
+	}
+
+	// yaml的key和value之间是": "，或者冒号在行尾
+	if semicolonIndex == len(line)-1 || line[semicolonIndex+1] == ' ' {
+		return YAML
+	}
+	return PROPERTIES
+}
+
+// 行尾有奇数个反斜杠表示续行
+func endWithContinuation(line string) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// Convert 在YAML、PROPERTIES、JSON之间进行任意的转换，from和to相同则原样返回
+func Convert(content string, from, to Format) (string, error) {
+	if from == to {
+		return content, nil
+	}
+
+	switch from {
+	case YAML:
+		switch to {
+		case PROPERTIES:
+			return YamlToProperties(content)
+		case JSON:
+			return YamlToJson(content)
+		}
+	case PROPERTIES:
+		switch to {
+		case YAML:
+			return PropertiesToYaml(content)
+		case JSON:
+			yamlContent, err := PropertiesToYaml(content)
+			if err != nil {
+				return "", err
+			}
+			return YamlToJson(yamlContent)
+		}
+	case JSON:
+		switch to {
+		case YAML:
+			return JsonToYaml(content)
+		case PROPERTIES:
+			yamlContent, err := JsonToYaml(content)
+			if err != nil {
+				return "", err
+			}
+			return YamlToProperties(yamlContent)
+		}
+	}
+	return "", &ConvertError{errMsg: fmt.Sprintf("not support convert from %v to %v", FormatName(from), FormatName(to))}
+}
+
+// FormatName 格式对应的名字
+func FormatName(format Format) string {
+	switch format {
+	case YAML:
+		return "yaml"
+	case PROPERTIES:
+		return "properties"
+	case JSON:
+		return "json"
+	case STRING:
+		return "string"
+	}
+	return "unknown"
+}
+
+// yaml解析出来的map的key为interface{}，json不支持，这里统一转换为string的key
+func normalizeYamlValue(value interface{}) interface{} {
+	switch data := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(data))
+		for key, item := range data {
+			result[fmt.Sprintf("%v", key)] = normalizeYamlValue(item)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(data))
+		for key, item := range data {
+			result[key] = normalizeYamlValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(data))
+		for index, item := range data {
+			result[index] = normalizeYamlValue(item)
+		}
+		return result
+	}
+	return value
+}
@@ -0,0 +1,208 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/**
+ * map <---> 环境变量，用于生成docker的--env-file和k8s的ConfigMap
+ *  a.b[0].c=value  <--->  PREFIX_A_B_0_C=value
+ * key中的'.'、'-'和数组下标都转换为'_'，并转为大写；
+ * 反向转换时key为小写，纯数字的段作为数组下标，因此原key中的'_'和大小写信息无法还原；
+ * docker的--env-file不处理引号和转义，MapToEnv生成不加引号的值，shell和dotenv使用MapToEnvQuoted
+ */
+
+// EnvSeparator 环境变量的key的分隔符
+var EnvSeparator = "_"
+
+// MapToEnv map转换为docker的--env-file格式的环境变量，每行一个，按照key排序；值原样输出，有换行的值无法表示，返回错误
+func MapToEnv(dataMap map[string]interface{}, prefix string) (string, error) {
+	return mapToEnv(dataMap, prefix, rawEnvValue)
+}
+
+// MapToEnvQuoted map转换为shell和dotenv格式的环境变量，有换行、首尾空白或者引号的值用双引号包裹并转义
+func MapToEnvQuoted(dataMap map[string]interface{}, prefix string) (string, error) {
+	return mapToEnv(dataMap, prefix, func(value string) (string, error) {
+		return quoteEnvValue(value), nil
+	})
+}
+
+func mapToEnv(dataMap map[string]interface{}, prefix string, valueOf func(string) (string, error)) (string, error) {
+	var envList []string
+	var err error
+	for key, value := range dataMap {
+		if envList, err = doMapToEnv(envList, value, envKeyJoin(envKeyOf(prefix), envKeyOf(key)), valueOf); err != nil {
+			return "", err
+		}
+	}
+	sort.Strings(envList)
+
+	var builder strings.Builder
+	for _, env := range envList {
+		builder.WriteString(env + NewLine)
+	}
+	return builder.String(), nil
+}
+
+// EnvToMap 环境变量转换为map，prefix不为空则只处理该前缀的变量；支持#注释、export前缀和MapToEnvQuoted生成的引号包裹的值
+func EnvToMap(contentOfEnv string, prefix string) (map[string]interface{}, error) {
+	envPrefix := envKeyOf(prefix)
+	if "" != envPrefix {
+		envPrefix += EnvSeparator
+	}
+
+	var document interface{}
+	for _, line := range strings.Split(contentOfEnv, NewLine) {
+		line = strings.TrimSpace(line)
+		if "" == line || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		index := strings.Index(line, SignEqual)
+		if index <= 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:index])
+		value := unquoteEnvValue(strings.TrimSpace(line[index+1:]))
+		if !strings.HasPrefix(strings.ToUpper(key), envPrefix) {
+			continue
+		}
+
+		tokens, err := envKeyToTokens(key[len(envPrefix):])
+		if err != nil {
+			return nil, err
+		}
+		document = putPropertyNode(document, tokens, value)
+	}
+
+	resultMap, ok := toPlainValue(document).(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{}, nil
+	}
+	return resultMap, nil
+}
+
+func doMapToEnv(envList []string, value interface{}, key string, valueOf func(string) (string, error)) ([]string, error) {
+	if value == nil {
+		return append(envList, key+SignEqual), nil
+	}
+
+	var err error
+	objectValue := reflect.ValueOf(value)
+	switch objectValue.Kind() {
+	case reflect.Map:
+		for mapR := objectValue.MapRange(); mapR.Next(); {
+			if envList, err = doMapToEnv(envList, mapR.Value().Interface(), envKeyJoin(key, envKeyOf(fmt.Sprintf("%v", mapR.Key().Interface()))), valueOf); err != nil {
+				return nil, err
+			}
+		}
+		return envList, nil
+	case reflect.Array, reflect.Slice:
+		for index := 0; index < objectValue.Len(); index++ {
+			if envList, err = doMapToEnv(envList, objectValue.Index(index).Interface(), envKeyJoin(key, strconv.Itoa(index)), valueOf); err != nil {
+				return nil, err
+			}
+		}
+		return envList, nil
+	}
+
+	valueStr, err := valueOf(fmt.Sprintf("%v", value))
+	if err != nil {
+		return nil, err
+	}
+	return append(envList, key+SignEqual+valueStr), nil
+}
+
+// docker的--env-file每行一个变量，值原样传给容器
+func rawEnvValue(value string) (string, error) {
+	if strings.ContainsAny(value, "\n\r") {
+		return "", &ConvertError{errMsg: "multi-line value is not supported by env-file, use MapToEnvQuoted: " + strconv.Quote(value)}
+	}
+	return value, nil
+}
+
+// 环境变量不支持多行，有换行、首尾空白或者会被当作引号包裹的值用双引号包裹，其中的\、"和换行转义
+func quoteEnvValue(value string) string {
+	if !strings.ContainsAny(value, "\n\r") && strings.TrimSpace(value) == value && unquoteEnvValue(value) == value {
+		return value
+	}
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n", "\r", "\\r").Replace(value) + "\""
+}
+
+// 转换为环境变量的key：a.b-c[0] -> A_B_C_0
+func envKeyOf(key string) string {
+	key = strings.ReplaceAll(key, "]", "")
+	key = strings.NewReplacer(".", EnvSeparator, "-", EnvSeparator, "[", EnvSeparator, " ", EnvSeparator).Replace(key)
+	return strings.ToUpper(key)
+}
+
+func envKeyJoin(prefix, key string) string {
+	if "" == prefix {
+		return key
+	}
+	if "" == key {
+		return prefix
+	}
+	return prefix + EnvSeparator + key
+}
+
+// 环境变量的key转换为路径：A_B_0_C -> a.b[0].c
+func envKeyToTokens(key string) ([]pathToken, error) {
+	var tokens []pathToken
+	for _, word := range strings.Split(strings.ToLower(key), EnvSeparator) {
+		if "" == word {
+			continue
+		}
+		if index, err := strconv.Atoi(word); err == nil && len(tokens) > 0 && index >= 0 {
+			tokens = append(tokens, pathToken{index: index})
+			continue
+		}
+		tokens = append(tokens, pathToken{name: word, index: -1})
+	}
+	if len(tokens) == 0 {
+		return nil, &ConvertError{errMsg: "illegal env key: " + key}
+	}
+	return tokens, nil
+}
+
+// 单引号包裹的值原样返回，双引号包裹的值还原其中的\\、\"、\n、\r和\t，其他的转义保持不变
+func unquoteEnvValue(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+	if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		return value[1 : len(value)-1]
+	}
+	if !strings.HasPrefix(value, "\"") || !strings.HasSuffix(value, "\"") {
+		return value
+	}
+
+	value = value[1 : len(value)-1]
+	var builder strings.Builder
+	for index := 0; index < len(value); index++ {
+		if value[index] != '\\' || index == len(value)-1 {
+			builder.WriteByte(value[index])
+			continue
+		}
+		index++
+		switch value[index] {
+		case '\\', '"':
+			builder.WriteByte(value[index])
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 't':
+			builder.WriteByte('\t')
+		default:
+			builder.WriteByte('\\')
+			builder.WriteByte(value[index])
+		}
+	}
+	return builder.String()
+}
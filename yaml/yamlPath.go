@@ -0,0 +1,793 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+/**
+ * yaml和json文档的路径查询与修改
+ *  1.路径：a.b[2].c
+ *  2.json-patch：RFC 6902，路径为json-pointer：/a/b/2/c
+ *  3.merge-patch：RFC 7386
+ * 修改后返回的文档格式与输入的格式一致，yaml的key保持原有的顺序
+ */
+
+type pathToken struct {
+	// map的key
+	name string
+	// 数组下标，-1表示不是下标
+	index int
+}
+
+// Get 获取文档中路径对应的值，路径不存在则返回nil
+func Get(content string, path string) (interface{}, error) {
+	document, _, err := decodeDocument(content)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	node, exist := lookupNode(document, tokens)
+	if !exist {
+		return nil, nil
+	}
+	return toPlainValue(node), nil
+}
+
+// Set 设置文档中路径对应的值，中间节点不存在则自动创建，返回修改后的文档
+func Set(content string, path string, value interface{}) (string, error) {
+	document, format, err := decodeDocument(content)
+	if err != nil {
+		return "", err
+	}
+
+	tokens, err := parsePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	document, err = setNode(document, tokens, toNode(value))
+	if err != nil {
+		return "", err
+	}
+	return encodeDocument(document, format, content)
+}
+
+// Delete 删除文档中路径对应的值，路径不存在则原样返回
+func Delete(content string, path string) (string, error) {
+	document, format, err := decodeDocument(content)
+	if err != nil {
+		return "", err
+	}
+
+	tokens, err := parsePath(path)
+	if err != nil {
+		return "", err
+	}
+	if len(tokens) == 0 {
+		return encodeDocument(nil, format, content)
+	}
+
+	if _, exist := lookupNode(document, tokens); !exist {
+		return content, nil
+	}
+	parent, _ := lookupNode(document, tokens[:len(tokens)-1])
+	document, err = replaceNode(document, tokens[:len(tokens)-1], removeChild(parent, tokens[len(tokens)-1]))
+	if err != nil {
+		return "", err
+	}
+	return encodeDocument(document, format, content)
+}
+
+// ApplyJsonPatch 应用json-patch（RFC 6902），patch为json或yaml格式的操作列表
+func ApplyJsonPatch(content string, patch string) (string, error) {
+	document, format, err := decodeDocument(content)
+	if err != nil {
+		return "", err
+	}
+
+	patchNode, _, err := decodeDocument(patch)
+	if err != nil {
+		return "", err
+	}
+	operations, ok := patchNode.([]interface{})
+	if !ok {
+		return "", &ConvertError{errMsg: "json patch must be an array of operations"}
+	}
+
+	for _, operation := range operations {
+		document, err = applyPatchOperation(document, operation)
+		if err != nil {
+			return "", err
+		}
+	}
+	return encodeDocument(document, format, content)
+}
+
+// ApplyMergePatch 应用merge-patch（RFC 7386），patch为json或yaml格式，值为null表示删除
+func ApplyMergePatch(content string, patch string) (string, error) {
+	document, format, err := decodeDocument(content)
+	if err != nil {
+		return "", err
+	}
+
+	patchNode, _, err := decodeDocument(patch)
+	if err != nil {
+		return "", err
+	}
+	return encodeDocument(mergePatch(document, patchNode), format, content)
+}
+
+// 解析路径：a.b[2].c，数组下标沿用peelArray的解析
+func parsePath(path string) ([]pathToken, error) {
+	var tokens []pathToken
+	if "" == path {
+		return tokens, nil
+	}
+
+	for _, word := range strings.Split(path, Dot) {
+		var indexes []int
+		name := word
+		for strings.HasSuffix(name, "]") {
+			var index int
+			name, index = peelArray(name)
+			if index < 0 {
+				return nil, &ConvertError{errMsg: "illegal array index in path: " + path}
+			}
+			indexes = append([]int{index}, indexes...)
+		}
+		if "" == name && (len(indexes) == 0 || len(tokens) > 0) {
+			return nil, &ConvertError{errMsg: "illegal path: " + path}
+		}
+		if "" != name {
+			tokens = append(tokens, pathToken{name: name, index: -1})
+		}
+		for _, index := range indexes {
+			tokens = append(tokens, pathToken{index: index})
+		}
+	}
+	return tokens, nil
+}
+
+// 解析json-pointer：/a/b/2/c，数字在数组中作为下标，"-"表示数组末尾
+func parsePointer(pointer string) ([]pathToken, error) {
+	var tokens []pathToken
+	if "" == pointer {
+		return tokens, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, &ConvertError{errMsg: "json pointer must start with '/': " + pointer}
+	}
+
+	for _, word := range strings.Split(pointer[1:], "/") {
+		word = strings.ReplaceAll(strings.ReplaceAll(word, "~1", "/"), "~0", "~")
+		tokens = append(tokens, pathToken{name: word, index: -1})
+	}
+	return tokens, nil
+}
+
+// 数组中的token转换为下标，"-"返回数组长度
+func arrayIndex(array []interface{}, token pathToken) (int, bool) {
+	if token.index >= 0 {
+		return token.index, true
+	}
+	if token.name == "-" {
+		return len(array), true
+	}
+	index, err := strconv.Atoi(token.name)
+	if err != nil || index < 0 || (len(token.name) > 1 && strings.HasPrefix(token.name, "0")) {
+		return 0, false
+	}
+	return index, true
+}
+
+func lookupNode(node interface{}, tokens []pathToken) (interface{}, bool) {
+	for _, token := range tokens {
+		switch data := node.(type) {
+		case yaml.MapSlice:
+			if token.index >= 0 {
+				return nil, false
+			}
+			itemIndex := mapSliceIndex(data, token.name)
+			if itemIndex < 0 {
+				return nil, false
+			}
+			node = data[itemIndex].Value
+		case []interface{}:
+			index, ok := arrayIndex(data, token)
+			if !ok || index >= len(data) {
+				return nil, false
+			}
+			node = data[index]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// 用value替换路径对应的节点，返回新的根节点
+func replaceNode(node interface{}, tokens []pathToken, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	token := tokens[0]
+	switch data := node.(type) {
+	case yaml.MapSlice:
+		if token.index >= 0 {
+			return nil, &ConvertError{errMsg: fmt.Sprintf("can not use index [%d] on object", token.index)}
+		}
+		itemIndex := mapSliceIndex(data, token.name)
+		if itemIndex < 0 {
+			return nil, &ConvertError{errMsg: "path not exist: " + token.name}
+		}
+		child, err := replaceNode(data[itemIndex].Value, tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		data[itemIndex].Value = child
+		return data, nil
+	case []interface{}:
+		index, ok := arrayIndex(data, token)
+		if !ok || index >= len(data) {
+			return nil, &ConvertError{errMsg: "array index out of range: " + tokenString(token)}
+		}
+		child, err := replaceNode(data[index], tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		data[index] = child
+		return data, nil
+	}
+	return nil, &ConvertError{errMsg: "path not exist: " + tokenString(token)}
+}
+
+// 设置路径对应的值，中间节点不存在时创建，返回新的根节点
+func setNode(node interface{}, tokens []pathToken, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	token := tokens[0]
+	if token.index >= 0 {
+		data, ok := node.([]interface{})
+		if node == nil {
+			data, ok = []interface{}{}, true
+		}
+		if !ok {
+			return nil, &ConvertError{errMsg: fmt.Sprintf("can not use index [%d] on non-array", token.index)}
+		}
+		if token.index > len(data) {
+			return nil, &ConvertError{errMsg: "array index out of range: " + tokenString(token)}
+		}
+
+		var child interface{}
+		if token.index < len(data) {
+			child = data[token.index]
+		}
+		child, err := setNode(child, tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		if token.index == len(data) {
+			return append(data, child), nil
+		}
+		data[token.index] = child
+		return data, nil
+	}
+
+	data, ok := node.(yaml.MapSlice)
+	if node == nil {
+		data, ok = yaml.MapSlice{}, true
+	}
+	if !ok {
+		return nil, &ConvertError{errMsg: "can not set key on non-object: " + token.name}
+	}
+
+	itemIndex := mapSliceIndex(data, token.name)
+	var child interface{}
+	if itemIndex >= 0 {
+		child = data[itemIndex].Value
+	}
+	child, err := setNode(child, tokens[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	if itemIndex >= 0 {
+		data[itemIndex].Value = child
+		return data, nil
+	}
+	return append(data, yaml.MapItem{Key: token.name, Value: child}), nil
+}
+
+// 删除节点的子节点，返回新的节点
+func removeChild(node interface{}, token pathToken) interface{} {
+	switch data := node.(type) {
+	case yaml.MapSlice:
+		itemIndex := mapSliceIndex(data, token.name)
+		if token.index >= 0 || itemIndex < 0 {
+			return node
+		}
+		return append(data[:itemIndex:itemIndex], data[itemIndex+1:]...)
+	case []interface{}:
+		index, ok := arrayIndex(data, token)
+		if !ok || index >= len(data) {
+			return node
+		}
+		return append(data[:index:index], data[index+1:]...)
+	}
+	return node
+}
+
+func applyPatchOperation(document interface{}, operation interface{}) (interface{}, error) {
+	operationMap, ok := operation.(yaml.MapSlice)
+	if !ok {
+		return nil, &ConvertError{errMsg: "json patch operation must be an object"}
+	}
+
+	op := nodeString(mapSliceValue(operationMap, "op"))
+	path, exist := mapSliceGet(operationMap, "path")
+	if !exist {
+		return nil, &ConvertError{errMsg: "json patch operation lack of 'path'"}
+	}
+	tokens, err := parsePointer(nodeString(path))
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "add":
+		value, exist := mapSliceGet(operationMap, "value")
+		if !exist {
+			return nil, &ConvertError{errMsg: "json patch 'add' lack of 'value'"}
+		}
+		return patchAdd(document, tokens, deepCopyNode(value))
+	case "remove":
+		return patchRemove(document, tokens)
+	case "replace":
+		value, exist := mapSliceGet(operationMap, "value")
+		if !exist {
+			return nil, &ConvertError{errMsg: "json patch 'replace' lack of 'value'"}
+		}
+		if _, exist := lookupNode(document, tokens); !exist {
+			return nil, &ConvertError{errMsg: "json patch 'replace' path not exist: " + nodeString(path)}
+		}
+		return replaceNode(document, tokens, deepCopyNode(value))
+	case "move", "copy":
+		from, exist := mapSliceGet(operationMap, "from")
+		if !exist {
+			return nil, &ConvertError{errMsg: "json patch '" + op + "' lack of 'from'"}
+		}
+		fromTokens, err := parsePointer(nodeString(from))
+		if err != nil {
+			return nil, err
+		}
+		value, exist := lookupNode(document, fromTokens)
+		if !exist {
+			return nil, &ConvertError{errMsg: "json patch '" + op + "' from not exist: " + nodeString(from)}
+		}
+		if op == "copy" {
+			return patchAdd(document, tokens, deepCopyNode(value))
+		}
+
+		fromPointer, toPointer := nodeString(from), nodeString(path)
+		if fromPointer == toPointer {
+			return document, nil
+		}
+		if strings.HasPrefix(toPointer, fromPointer+"/") {
+			return nil, &ConvertError{errMsg: "json patch 'move' from can not be the prefix of path"}
+		}
+		document, err = patchRemove(document, fromTokens)
+		if err != nil {
+			return nil, err
+		}
+		return patchAdd(document, tokens, value)
+	case "test":
+		value, exist := mapSliceGet(operationMap, "value")
+		if !exist {
+			return nil, &ConvertError{errMsg: "json patch 'test' lack of 'value'"}
+		}
+		actual, exist := lookupNode(document, tokens)
+		if !exist || !nodeEqual(actual, value) {
+			return nil, &ConvertError{errMsg: "json patch 'test' failed, path: " + nodeString(path)}
+		}
+		return document, nil
+	}
+	return nil, &ConvertError{errMsg: "json patch not support op: " + op}
+}
+
+func patchAdd(document interface{}, tokens []pathToken, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	parentTokens, token := tokens[:len(tokens)-1], tokens[len(tokens)-1]
+	parent, exist := lookupNode(document, parentTokens)
+	if !exist {
+		return nil, &ConvertError{errMsg: "json patch 'add' parent not exist"}
+	}
+
+	var newParent interface{}
+	switch data := parent.(type) {
+	case yaml.MapSlice:
+		if itemIndex := mapSliceIndex(data, token.name); itemIndex >= 0 {
+			data[itemIndex].Value = value
+			newParent = data
+		} else {
+			newParent = append(data, yaml.MapItem{Key: token.name, Value: value})
+		}
+	case []interface{}:
+		index, ok := arrayIndex(data, token)
+		if !ok || index > len(data) {
+			return nil, &ConvertError{errMsg: "json patch 'add' array index out of range: " + token.name}
+		}
+		result := make([]interface{}, 0, len(data)+1)
+		result = append(result, data[:index]...)
+		result = append(result, value)
+		newParent = append(result, data[index:]...)
+	default:
+		return nil, &ConvertError{errMsg: "json patch 'add' parent is not container"}
+	}
+	return replaceNode(document, parentTokens, newParent)
+}
+
+func patchRemove(document interface{}, tokens []pathToken) (interface{}, error) {
+	if _, exist := lookupNode(document, tokens); !exist || len(tokens) == 0 {
+		return nil, &ConvertError{errMsg: "json patch 'remove' path not exist"}
+	}
+
+	parentTokens := tokens[:len(tokens)-1]
+	parent, _ := lookupNode(document, parentTokens)
+	return replaceNode(document, parentTokens, removeChild(parent, tokens[len(tokens)-1]))
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchMap, ok := patch.(yaml.MapSlice)
+	if !ok {
+		return deepCopyNode(patch)
+	}
+
+	targetMap, ok := target.(yaml.MapSlice)
+	if !ok {
+		targetMap = yaml.MapSlice{}
+	}
+	for _, item := range patchMap {
+		name := fmt.Sprintf("%v", item.Key)
+		itemIndex := mapSliceIndex(targetMap, name)
+		if item.Value == nil {
+			if itemIndex >= 0 {
+				targetMap = append(targetMap[:itemIndex:itemIndex], targetMap[itemIndex+1:]...)
+			}
+			continue
+		}
+
+		if itemIndex >= 0 {
+			targetMap[itemIndex].Value = mergePatch(targetMap[itemIndex].Value, item.Value)
+		} else {
+			targetMap = append(targetMap, yaml.MapItem{Key: name, Value: mergePatch(nil, item.Value)})
+		}
+	}
+	return targetMap
+}
+
+func mapSliceIndex(data yaml.MapSlice, name string) int {
+	for index, item := range data {
+		if fmt.Sprintf("%v", item.Key) == name {
+			return index
+		}
+	}
+	return -1
+}
+
+func mapSliceGet(data yaml.MapSlice, name string) (interface{}, bool) {
+	itemIndex := mapSliceIndex(data, name)
+	if itemIndex < 0 {
+		return nil, false
+	}
+	return data[itemIndex].Value, true
+}
+
+func mapSliceValue(data yaml.MapSlice, name string) interface{} {
+	value, _ := mapSliceGet(data, name)
+	return value
+}
+
+func tokenString(token pathToken) string {
+	if token.index >= 0 {
+		return "[" + strconv.Itoa(token.index) + "]"
+	}
+	return token.name
+}
+
+func nodeString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// 比较两个节点，数字按照数值比较，对象不区分key的顺序
+func nodeEqual(left, right interface{}) bool {
+	return reflect.DeepEqual(comparableValue(toPlainValue(left)), comparableValue(toPlainValue(right)))
+}
+
+func comparableValue(value interface{}) interface{} {
+	switch data := value.(type) {
+	case map[string]interface{}:
+		for key, item := range data {
+			data[key] = comparableValue(item)
+		}
+		return data
+	case []interface{}:
+		for index, item := range data {
+			data[index] = comparableValue(item)
+		}
+		return data
+	}
+
+	valueOf := reflect.ValueOf(value)
+	switch valueOf.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(valueOf.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(valueOf.Uint())
+	case reflect.Float32, reflect.Float64:
+		return valueOf.Float()
+	}
+	return value
+}
+
+// 转换为文档内部的节点：对象为yaml.MapSlice，数组为[]interface{}
+func toNode(value interface{}) interface{} {
+	switch data := value.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return data
+	case yaml.MapSlice:
+		result := make(yaml.MapSlice, 0, len(data))
+		for _, item := range data {
+			result = append(result, yaml.MapItem{Key: fmt.Sprintf("%v", item.Key), Value: toNode(item.Value)})
+		}
+		return result
+	case map[string]interface{}:
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		result := make(yaml.MapSlice, 0, len(data))
+		for _, key := range keys {
+			result = append(result, yaml.MapItem{Key: key, Value: toNode(data[key])})
+		}
+		return result
+	case map[interface{}]interface{}:
+		keys := make([]string, 0, len(data))
+		values := make(map[string]interface{}, len(data))
+		for key, item := range data {
+			keys = append(keys, fmt.Sprintf("%v", key))
+			values[fmt.Sprintf("%v", key)] = item
+		}
+		sort.Strings(keys)
+		result := make(yaml.MapSlice, 0, len(data))
+		for _, key := range keys {
+			result = append(result, yaml.MapItem{Key: key, Value: toNode(values[key])})
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(data))
+		for index, item := range data {
+			result[index] = toNode(item)
+		}
+		return result
+	}
+
+	// 其他的类型，比如结构体，按照yaml的tag进行转换
+	content, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	node, err := decodeYamlNode(content)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return node
+}
+
+func deepCopyNode(node interface{}) interface{} {
+	return toNode(node)
+}
+
+// 节点转换为常用的go类型：对象为map[string]interface{}，数组为[]interface{}
+func toPlainValue(node interface{}) interface{} {
+	switch data := node.(type) {
+	case yaml.MapSlice:
+		result := make(map[string]interface{}, len(data))
+		for _, item := range data {
+			result[fmt.Sprintf("%v", item.Key)] = toPlainValue(item.Value)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(data))
+		for index, item := range data {
+			result[index] = toPlainValue(item)
+		}
+		return result
+	}
+	return node
+}
+
+// 解析文档，返回根节点和文档的格式
+func decodeDocument(content string) (interface{}, Format, error) {
+	format := Detect(content)
+	switch format {
+	case JSON:
+		node, err := decodeJsonNode(content)
+		return node, JSON, err
+	case PROPERTIES:
+		yamlContent, err := PropertiesToYaml(content)
+		if err != nil {
+			return nil, PROPERTIES, err
+		}
+		node, err := decodeYamlNode([]byte(yamlContent))
+		return node, PROPERTIES, err
+	}
+	node, err := decodeYamlNode([]byte(content))
+	return node, YAML, err
+}
+
+func decodeYamlNode(content []byte) (interface{}, error) {
+	if "" == strings.TrimSpace(string(content)) {
+		return nil, nil
+	}
+
+	var mapSlice yaml.MapSlice
+	if err := yaml.Unmarshal(content, &mapSlice); err == nil {
+		return mapSlice, nil
+	}
+
+	var data interface{}
+	if err := yaml.Unmarshal(content, &data); err != nil {
+		return nil, err
+	}
+	return toNode(data), nil
+}
+
+// 按照顺序解析json
+func decodeJsonNode(content string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.UseNumber()
+	node, err := decodeJsonValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, &ConvertError{errMsg: "invalid json content"}
+	}
+	return node, nil
+}
+
+func decodeJsonValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch data := token.(type) {
+	case json.Delim:
+		if data == '{' {
+			result := yaml.MapSlice{}
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeJsonValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, yaml.MapItem{Key: keyToken.(string), Value: value})
+			}
+			_, err = decoder.Token()
+			return result, err
+		}
+
+		result := []interface{}{}
+		for decoder.More() {
+			value, err := decodeJsonValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		_, err = decoder.Token()
+		return result, err
+	case json.Number:
+		if intValue, err := data.Int64(); err == nil {
+			return int(intValue), nil
+		}
+		return data.Float64()
+	}
+	return token, nil
+}
+
+// 按照格式输出文档，json的输入有换行时输出带缩进的格式
+func encodeDocument(node interface{}, format Format, original string) (string, error) {
+	switch format {
+	case JSON:
+		var buffer bytes.Buffer
+		if err := encodeJsonNode(&buffer, node); err != nil {
+			return "", err
+		}
+		if !strings.Contains(strings.TrimSpace(original), NewLine) {
+			return buffer.String(), nil
+		}
+
+		var indentBuffer bytes.Buffer
+		if err := json.Indent(&indentBuffer, buffer.Bytes(), "", IndentBlanks); err != nil {
+			return "", err
+		}
+		return indentBuffer.String(), nil
+	case PROPERTIES:
+		yamlContent, err := ObjectToYaml(node)
+		if err != nil {
+			return "", err
+		}
+		return YamlToProperties(yamlContent)
+	}
+	if node == nil {
+		return "", nil
+	}
+	return ObjectToYaml(node)
+}
+
+func encodeJsonNode(buffer *bytes.Buffer, node interface{}) error {
+	switch data := node.(type) {
+	case yaml.MapSlice:
+		buffer.WriteByte('{')
+		for index, item := range data {
+			if index > 0 {
+				buffer.WriteByte(',')
+			}
+			key, _ := json.Marshal(fmt.Sprintf("%v", item.Key))
+			buffer.Write(key)
+			buffer.WriteByte(':')
+			if err := encodeJsonNode(buffer, item.Value); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte('}')
+		return nil
+	case []interface{}:
+		buffer.WriteByte('[')
+		for index, item := range data {
+			if index > 0 {
+				buffer.WriteByte(',')
+			}
+			if err := encodeJsonNode(buffer, item); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(']')
+		return nil
+	}
+
+	value, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	buffer.Write(value)
+	return nil
+}
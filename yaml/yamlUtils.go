@@ -42,6 +42,9 @@ var ArrayBlanks = "- "
 // NewLineDom yaml的value换行符
 var YamlNewLineDom = "|\n"
 
+// yaml的value换行符，value的末尾没有换行
+var yamlNewLineStripDom = "|-\n"
+
 var rangePattern = regexp.MustCompile("^(.*)\\[(\\d*)\\]$")
 
 type TypeEnum int8
@@ -182,18 +185,14 @@ func YamlToMap(contentOfYaml string) (map[string]interface{}, error) {
 }
 
 func YamlToJson(contentOfYaml string) (string, error) {
-	if contentOfYaml != "-" && strings.Contains(contentOfYaml, ":") {
-		return "", &ConvertError{errMsg: "the content is invalidate for json"}
-	}
-
 	var data interface{}
 	err := yaml.Unmarshal([]byte(contentOfYaml), &data)
 	if err != nil {
-		log.Printf("YamlToList, error: %v, content: %v", err, contentOfYaml)
+		log.Printf("YamlToJson, error: %v, content: %v", err, contentOfYaml)
 		return "", err
 	}
 
-	jsonStr, err := json.Marshal(data)
+	jsonStr, err := json.Marshal(normalizeYamlValue(data))
 	if err != nil {
 		return "", err
 	}
@@ -210,18 +209,7 @@ func YamlToKvList(contentOfYaml string) ([]StringPair, error) {
 		return nil, err
 	}
 
-	propertiesLineWordList := GetPropertiesItemLineList(property)
-	var pairs []StringPair
-	for _, element := range propertiesLineWordList {
-		element = strings.TrimSpace(element)
-		if "" == element {
-			continue
-		}
-		values := strings.SplitN(element, SignEqual, 2)
-		pairs = append(pairs, StringPair{Left: values[0], Right: values[1]})
-	}
-
-	return pairs, nil
+	return parseProperties(property)
 }
 
 func YamlToList(contentOfYaml string) ([]interface{}, error) {
@@ -258,43 +246,30 @@ func YamlCheck(content string) error {
 	return nil
 }
 
+// PropertiesToMap properties转换为map，key和value都是转义后的内容
 func PropertiesToMap(contentOfProperties string) (map[string]interface{}, error) {
-	if !strings.Contains(contentOfProperties, "=") {
-		return nil, &ConvertError{errMsg: "the content is illegal for properties"}
+	pairs, err := parseProperties(contentOfProperties)
+	if err != nil {
+		return nil, err
 	}
 
 	var resultMap = make(map[string]interface{})
-	propertiesLineWordList := GetPropertiesItemLineList(contentOfProperties)
-	for _, line := range propertiesLineWordList {
-		line = strings.TrimSpace(line)
-		if "" == line {
-			continue
-		}
-
-		lineKVs := strings.SplitN(line, "=", 2)
-		key := lineKVs[0]
-		value := lineKVs[1]
-
-		if strings.Contains(value, "\n") {
-			value = YamlNewLineDom + value
-		}
-
-		resultMap[key] = value
+	for _, pair := range pairs {
+		resultMap[pair.Left] = pair.Right
 	}
 
 	return resultMap, nil
 }
 
 func propertiesAppendPrefixKey(key string, propertiesContent string) (string, error) {
-	itemLines := GetPropertiesItemLineList(propertiesContent)
-	var datas []string
-	for _, line := range itemLines {
-		if !strings.Contains(line, SignEqual) {
-			continue
-		}
+	pairs, err := parseProperties(propertiesContent)
+	if err != nil {
+		return "", err
+	}
 
-		kvs := strings.SplitN(line, SignEqual, 2)
-		datas = append(datas, key+Dot+kvs[0]+SignEqual+kvs[1])
+	var datas []string
+	for _, pair := range pairs {
+		datas = append(datas, escapePropertiesKey(key+Dot+pair.Left)+SignEqual+escapePropertiesValue(pair.Right))
 	}
 
 	return strings.Join(datas, NewLine), nil
@@ -322,28 +297,24 @@ func deepPut(dataMap map[string]interface{}, key string, value interface{}) map[
 func PropertiesToYaml(contentOfProperties string) (string, error) {
 	var yamlLineList []string
 	var yamlNodes []YamlNode
-	propertiesLineWordList := GetPropertiesItemLineList(contentOfProperties)
-	for _, line := range propertiesLineWordList {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			// 注释数据不要
-			if strings.HasPrefix(line, "#") {
-				continue
-			}
-
-			index := strings.Index(line, SignEqual)
-			if index > -1 {
-				key := line[:index]
-				value := line[index+1:]
-
-				if strings.Contains(value, "\n") {
-					value = YamlNewLineDom + value
-				}
+	pairs, err := parseProperties(contentOfProperties)
+	if err != nil {
+		return "", err
+	}
+	for _, pair := range pairs {
+		key := pair.Left
+		value := pair.Right
 
-				lineWordList := strings.Split(key, ".")
-				lineWordList, yamlNodes = wordToNode(lineWordList, yamlNodes, nil, false, -1, appendSpaceForArrayValue(value))
+		if strings.Contains(value, NewLine) {
+			if strings.HasSuffix(value, NewLine) {
+				value = YamlNewLineDom + strings.TrimSuffix(value, NewLine)
+			} else {
+				value = yamlNewLineStripDom + value
 			}
 		}
+
+		lineWordList := strings.Split(key, ".")
+		lineWordList, yamlNodes = wordToNode(lineWordList, yamlNodes, nil, false, -1, appendSpaceForArrayValue(value))
 	}
 	yamlLineList = formatPropertiesToYaml(yamlLineList, yamlNodes, false, "")
 	return strings.Join(yamlLineList, "\n") + "\n", nil
@@ -362,6 +333,10 @@ func ObjectToYaml(value interface{}) (string, error) {
 func MapToProperties(dataMap map[string]interface{}) (string, error) {
 	var propertyStrList []string
 	for key, value := range dataMap {
+		if value == nil {
+			propertyStrList = append(propertyStrList, escapePropertiesKey(key)+SignEqual)
+			continue
+		}
 		valueKind := reflect.TypeOf(value).Kind()
 		switch valueKind {
 		case reflect.Map:
@@ -377,10 +352,9 @@ func MapToProperties(dataMap map[string]interface{}) (string, error) {
 			}
 		case reflect.String:
 			objectValue := reflect.ValueOf(value)
-			objectValueStr := strings.ReplaceAll(objectValue.String(), "\n", "\\\n")
-			propertyStrList = append(propertyStrList, prefixWithDOT("")+key+SignEqual+objectValueStr)
+			propertyStrList = append(propertyStrList, escapePropertiesKey(prefixWithDOT("")+key)+SignEqual+escapePropertiesValue(objectValue.String()))
 		default:
-			propertyStrList = append(propertyStrList, prefixWithDOT("")+key+SignEqual+fmt.Sprintf("%v", value))
+			propertyStrList = append(propertyStrList, escapePropertiesKey(prefixWithDOT("")+key)+SignEqual+escapePropertiesValue(fmt.Sprintf("%v", value)))
 		}
 	}
 	resultStr := ""
@@ -404,7 +378,7 @@ func KvToProperties(key, value string, valueType TypeEnum) (string, error) {
 	case PROPERTIES:
 		return propertiesAppendPrefixKey(key, value)
 	case STRING:
-		return key + "=" + appendSpaceForArrayValue(value), nil
+		return escapePropertiesKey(key) + SignEqual + escapePropertiesValue(value), nil
 	default:
 		break
 	}
@@ -439,29 +413,18 @@ func PropertiesEntityToYaml(properties Properties) (string, error) {
 
 	var content = ""
 	for key, value := range properties.Value {
-		content += key + "=" + value + "\n"
+		content += escapePropertiesKey(key) + SignEqual + escapePropertiesValue(value) + NewLine
 	}
 	return PropertiesToYaml(content)
 }
 
+// GetPropertiesItemLineList 获取properties的逻辑行：续行已合并，空行和注释行已去掉，key和value未转义
 func GetPropertiesItemLineList(content string) []string {
 	if "" == content {
 		return []string{}
 	}
 
-	lineList := strings.Split(content, NewLine)
-	var itemLineList []string
-	var stringAppender string
-	for _, line := range lineList {
-		if strings.HasSuffix(content, "\\") {
-			stringAppender += line + "\n"
-		} else {
-			stringAppender += line
-			itemLineList = append(itemLineList, stringAppender)
-			stringAppender = ""
-		}
-	}
-	return itemLineList
+	return readLogicalLines(content)
 }
 
 func formatPropertiesToYaml(yamlLineList []string, yamlNodes []YamlNode, lastNodeArrayFlag bool, blanks string) []string {
@@ -590,6 +553,9 @@ func wordToNode(lineWordList []string, nodeList []YamlNode, parentNode *YamlNode
 }
 
 func doMapToProperties(propertyStrList []string, value interface{}, prefix string) []string {
+	if value == nil {
+		return append(propertyStrList, escapePropertiesKey(prefix)+SignEqual)
+	}
 	valueKind := reflect.TypeOf(value).Kind()
 	switch valueKind {
 	case reflect.Map:
@@ -614,11 +580,10 @@ func doMapToProperties(propertyStrList []string, value interface{}, prefix strin
 		}
 	case reflect.String:
 		objectValue := reflect.ValueOf(value)
-		objectValueStr := strings.ReplaceAll(objectValue.String(), "\n", "\\\n")
-		propertyStrList = append(propertyStrList, prefix+SignEqual+objectValueStr)
+		propertyStrList = append(propertyStrList, escapePropertiesKey(prefix)+SignEqual+escapePropertiesValue(objectValue.String()))
 	default:
 		objectValue := fmt.Sprintf("%v", reflect.ValueOf(value))
-		propertyStrList = append(propertyStrList, prefix+SignEqual+objectValue)
+		propertyStrList = append(propertyStrList, escapePropertiesKey(prefix)+SignEqual+escapePropertiesValue(objectValue))
 	}
 	return propertyStrList
 }
@@ -681,22 +646,23 @@ func peelArray(nodeName string) (string, int) {
 // }
 //
 func appendSpaceForArrayValue(value string) string {
-	if !strings.HasPrefix(value, YamlNewLineDom) {
+	var dom string
+	if strings.HasPrefix(value, YamlNewLineDom) {
+		dom = YamlNewLineDom
+	} else if strings.HasPrefix(value, yamlNewLineStripDom) {
+		dom = yamlNewLineStripDom
+	} else {
 		return value
 	}
 
-	value = value[len(YamlNewLineDom):]
-	valueTems := strings.Split(value, "\\n")
+	value = value[len(dom):]
+	valueTems := strings.Split(value, NewLine)
 
 	strs := []string{}
 	for _, element := range valueTems {
-		tem := element
-		if strings.HasSuffix(element, "\\") {
-			tem = element[:len(element)-1]
-		}
-		strs = append(strs, IndentBlanks+tem)
+		strs = append(strs, IndentBlanks+element)
 	}
-	return YamlNewLineDom + strings.Join(strs, "\n")
+	return dom + strings.Join(strs, NewLine)
 }
 
 func stringValueWrap(value string) string {